@@ -5,6 +5,7 @@ import (
 
 	"golang-ecommerce-search/internal/config"
 	"golang-ecommerce-search/internal/delivery/http/handler"
+	"golang-ecommerce-search/internal/embedding"
 	"golang-ecommerce-search/internal/repository/elasticsearch"
 	"golang-ecommerce-search/internal/repository/mongodb"
 	"golang-ecommerce-search/internal/service"
@@ -54,20 +55,97 @@ func main() {
 	// Initialize repositories and services
 	productRepo := mongodb.NewProductRepository(mongoClient.GetDatabase(), cfg.MongoDB.Collection)
 	esRepo := elasticsearch.NewProductRepository(esClient.GetClient(), cfg.Elasticsearch.Index)
-	productService := service.NewProductService(esRepo, productRepo, kafkaProducer, cfg)
-	productHandler := handler.NewProductHandler(productService)
+	embedder, err := embedding.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create embedder: %v", err)
+	}
+	dimensions := 0
+	if embedder != nil {
+		dimensions = embedder.Dimensions()
+	}
+	if err := esRepo.EnsureMapping(dimensions); err != nil {
+		log.Fatalf("Failed to set up the Elasticsearch mapping: %v", err)
+	}
+	if versioned, err := productRepo.EnsureVersions(); err != nil {
+		log.Fatalf("Failed to set the version of unversioned products: %v", err)
+	} else if versioned > 0 {
+		log.Printf("Set version 1 on %d products stored before versioning", versioned)
+	}
+	auditRepo := mongodb.NewAuditRepository(mongoClient.GetDatabase(), cfg.MongoDB.AuditCollection)
+	productService := service.NewProductService(esRepo, productRepo, auditRepo, embedder, kafkaProducer, cfg)
+	coPurchaseRepo := mongodb.NewCoPurchaseRepository(mongoClient.GetDatabase(), cfg.MongoDB.CoPurchaseCollection)
+	if err := coPurchaseRepo.EnsureIndexes(); err != nil {
+		log.Fatalf("Failed to create the co-purchase indexes: %v", err)
+	}
+	purchaseService := service.NewPurchaseService(coPurchaseRepo, productRepo, kafkaProducer, cfg)
+	purchaseHandler := handler.NewPurchaseHandler(purchaseService)
+	searchLogRepo := mongodb.NewSearchLogRepository(mongoClient.GetDatabase(), cfg.MongoDB.SearchLogCollection)
+	searchEventRepo := mongodb.NewSearchEventRepository(mongoClient.GetDatabase(), cfg.MongoDB.SearchEventCollection)
+	analyticsService := service.NewAnalyticsService(searchLogRepo, searchEventRepo, kafkaProducer, cfg)
+	analyticsHandler := handler.NewAnalyticsHandler(analyticsService)
+	experimentRepo := mongodb.NewExperimentRepository(mongoClient.GetDatabase(), cfg.MongoDB.ExperimentCollection)
+	experimentService := service.NewExperimentService(experimentRepo, searchLogRepo)
+	experimentHandler := handler.NewExperimentHandler(experimentService)
+	ruleRepo := mongodb.NewRuleRepository(mongoClient.GetDatabase(), cfg.MongoDB.RuleCollection)
+	ruleService := service.NewRuleService(ruleRepo)
+	ruleService.StartRefresh()
+	ruleHandler := handler.NewRuleHandler(ruleService)
+	queryUnderstandingService := service.NewQueryUnderstandingService(esRepo)
+	variantHandler := handler.NewVariantHandler(productService)
+	inventoryHandler := handler.NewInventoryHandler(productService)
+	lifecycleHandler := handler.NewLifecycleHandler(productService)
+	productHandler := handler.NewProductHandler(productService, experimentService, ruleService, queryUnderstandingService)
 
 	// Initialize Gin router
 	router := gin.Default()
+	router.Use(handler.RequestID())
 
 	// Register routes
 	router.POST("/products", productHandler.Create)
+	router.POST("/products/bulk", productHandler.Bulk)
 	router.PUT("/products/:id", productHandler.Update)
+	router.PATCH("/products/:id", productHandler.Patch)
 	router.DELETE("/products/:id", productHandler.Delete)
 	router.GET("/products/:id", productHandler.Get)
 	router.GET("/products/search", productHandler.Search)
+	router.GET("/products/:id/similar", productHandler.Similar)
+	router.GET("/products/:id/history", productHandler.History)
+	router.POST("/products/:id/revert", productHandler.Revert)
 	router.POST("/products/:id/views", productHandler.IncrementViews)
 	router.POST("/products/:id/buys", productHandler.IncrementBuys)
+	router.GET("/products/:id/bought-together", purchaseHandler.BoughtTogether)
+	router.GET("/products/:id/variants", variantHandler.List)
+	router.POST("/products/:id/variants", variantHandler.Create)
+	router.PUT("/products/:id/variants/:sku", variantHandler.Update)
+	router.DELETE("/products/:id/variants/:sku", variantHandler.Delete)
+	router.POST("/products/:id/stock/reserve", inventoryHandler.Reserve)
+	router.POST("/products/:id/stock/release", inventoryHandler.Release)
+	router.PUT("/products/:id/stock", inventoryHandler.Set)
+	router.POST("/purchases", purchaseHandler.RecordPurchase)
+	router.POST("/search/events", analyticsHandler.RecordSearchEvent)
+
+	// Register admin routes
+	admin := router.Group("/admin")
+	admin.GET("/search/explain", productHandler.Explain)
+	admin.GET("/products", lifecycleHandler.List)
+	admin.PUT("/products/:id/status", lifecycleHandler.SetStatus)
+	admin.POST("/products/:id/restore", lifecycleHandler.Restore)
+	admin.GET("/analytics/top-queries", analyticsHandler.TopQueries)
+	admin.GET("/analytics/zero-result-queries", analyticsHandler.ZeroResultQueries)
+	admin.GET("/analytics/low-ctr-queries", analyticsHandler.LowCTRQueries)
+	admin.GET("/analytics/ranking", analyticsHandler.RankingStats)
+	admin.POST("/experiments", experimentHandler.Create)
+	admin.GET("/experiments", experimentHandler.List)
+	admin.GET("/experiments/:id", experimentHandler.Get)
+	admin.PUT("/experiments/:id", experimentHandler.Update)
+	admin.DELETE("/experiments/:id", experimentHandler.Delete)
+	admin.GET("/experiments/:id/report", experimentHandler.Report)
+	admin.POST("/rules", ruleHandler.Create)
+	admin.GET("/rules", ruleHandler.List)
+	admin.GET("/rules/:id", ruleHandler.Get)
+	admin.PUT("/rules/:id", ruleHandler.Update)
+	admin.DELETE("/rules/:id", ruleHandler.Delete)
+	admin.POST("/rules/refresh", ruleHandler.Refresh)
 
 	// Start server
 	if err := router.Run(":8080"); err != nil {
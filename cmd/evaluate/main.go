@@ -0,0 +1,166 @@
+// Command evaluate measures search relevance offline. It runs every query of a
+// judgment list through the Elasticsearch search with a ranking profile and
+// reports NDCG@k, precision@k and MRR per query and overall. With -compare it
+// runs a second profile and reports the difference per query.
+//
+//	go run ./cmd/evaluate -judgments judgments.csv -profile default -compare popularity
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"golang-ecommerce-search/internal/config"
+	"golang-ecommerce-search/internal/domain"
+	"golang-ecommerce-search/internal/evaluation"
+	"golang-ecommerce-search/internal/repository/elasticsearch"
+	"golang-ecommerce-search/pkg/esclient"
+)
+
+func main() {
+	configPath := flag.String("config", "config/config.yaml", "path to the configuration file")
+	judgmentsPath := flag.String("judgments", "", "CSV judgment list with query, product_id and grade columns")
+	profile := flag.String("profile", domain.DefaultRankingProfile, "ranking profile to evaluate")
+	compare := flag.String("compare", "", "second ranking profile to compare against -profile")
+	k := flag.Int("k", 10, "number of results evaluated per query")
+	flag.Parse()
+
+	if *judgmentsPath == "" {
+		log.Fatal("-judgments is required")
+	}
+	if *k < 1 {
+		log.Fatal("-k must be positive")
+	}
+	for _, name := range []string{*profile, *compare} {
+		if _, ok := domain.LookupRankingProfile(name); name != "" && !ok {
+			log.Fatalf("Unknown ranking profile %q", name)
+		}
+	}
+
+	file, err := os.Open(*judgmentsPath)
+	if err != nil {
+		log.Fatalf("Failed to open judgment list: %v", err)
+	}
+	judgments, err := evaluation.ReadJudgments(file)
+	file.Close()
+	if err != nil {
+		log.Fatalf("Failed to read judgment list: %v", err)
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	esClient, err := esclient.NewClient(&esclient.Config{
+		Addresses: cfg.Elasticsearch.Addresses,
+		Username:  cfg.Elasticsearch.Username,
+		Password:  cfg.Elasticsearch.Password,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create Elasticsearch client: %v", err)
+	}
+	esRepo := elasticsearch.NewProductRepository(esClient.GetClient(), cfg.Elasticsearch.Index)
+
+	report, err := evaluate(esRepo, judgments, *profile, *k)
+	if err != nil {
+		log.Fatalf("Failed to evaluate profile %q: %v", *profile, err)
+	}
+
+	if *compare == "" {
+		printReport(report)
+		return
+	}
+
+	other, err := evaluate(esRepo, judgments, *compare, *k)
+	if err != nil {
+		log.Fatalf("Failed to evaluate profile %q: %v", *compare, err)
+	}
+	printDiff(report, other)
+}
+
+// evaluate runs the queries of the judgment list with a ranking profile. Rules,
+// query interpretation and experiments are left out so only ranking is measured.
+func evaluate(repo elasticsearch.ProductRepository, judgments *evaluation.JudgmentList, profile string, k int) (*evaluation.Report, error) {
+	queries := make([]evaluation.QueryMetrics, 0, len(judgments.Queries))
+	for _, query := range judgments.Queries {
+		result, err := repo.Search(domain.SearchParams{
+			Query:          query,
+			RankingProfile: profile,
+			Page:           1,
+			PageSize:       k,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("query %q: %w", query, err)
+		}
+
+		ranked := make([]string, len(result.Products))
+		for i, product := range result.Products {
+			ranked[i] = product.ID
+		}
+		queries = append(queries, evaluation.Evaluate(query, ranked, judgments.Grades[query], k))
+	}
+	return evaluation.NewReport(profile, k, queries), nil
+}
+
+func printReport(report *evaluation.Report) {
+	fmt.Printf("Ranking profile: %s\n\n", report.Profile)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "QUERY\tNDCG@%d\tP@%d\tRR\n", report.K, report.K)
+	for _, metrics := range append(report.Queries, report.Mean) {
+		fmt.Fprintf(w, "%s\t%.4f\t%.4f\t%.4f\n", metrics.Query, metrics.NDCG, metrics.Precision, metrics.ReciprocalRank)
+	}
+	w.Flush()
+}
+
+// printDiff compares two reports over the same judgment list, listing the
+// queries with the largest NDCG change first
+func printDiff(base, other *evaluation.Report) {
+	fmt.Printf("Ranking profiles: %s (A) vs %s (B)\n\n", base.Profile, other.Profile)
+
+	indexes := make([]int, len(base.Queries))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	delta := func(i int) float64 {
+		return other.Queries[i].NDCG - base.Queries[i].NDCG
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return abs(delta(indexes[a])) > abs(delta(indexes[b]))
+	})
+
+	var improved, regressed int
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "QUERY\tNDCG@%d A\tNDCG@%d B\tDELTA\tP@%d A\tP@%d B\tRR A\tRR B\n", base.K, base.K, base.K, base.K)
+	for _, i := range indexes {
+		a, b := base.Queries[i], other.Queries[i]
+		switch d := delta(i); {
+		case d > 0:
+			improved++
+		case d < 0:
+			regressed++
+		}
+		printDiffRow(w, a, b)
+	}
+	printDiffRow(w, base.Mean, other.Mean)
+	w.Flush()
+
+	fmt.Printf("\n%d queries improved, %d regressed, %d unchanged\n", improved, regressed, len(indexes)-improved-regressed)
+}
+
+func printDiffRow(w *tabwriter.Writer, a, b evaluation.QueryMetrics) {
+	fmt.Fprintf(w, "%s\t%.4f\t%.4f\t%+.4f\t%.4f\t%.4f\t%.4f\t%.4f\n",
+		a.Query, a.NDCG, b.NDCG, b.NDCG-a.NDCG, a.Precision, b.Precision, a.ReciprocalRank, b.ReciprocalRank)
+}
+
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -5,6 +5,7 @@ import (
 
 	"golang-ecommerce-search/internal/config"
 	"golang-ecommerce-search/internal/delivery/kafka"
+	"golang-ecommerce-search/internal/embedding"
 	"golang-ecommerce-search/internal/repository/elasticsearch"
 	"golang-ecommerce-search/internal/repository/mongodb"
 	"golang-ecommerce-search/internal/service"
@@ -56,11 +57,37 @@ func main() {
 	mongoRepo := mongodb.NewProductRepository(mongoClient.GetDatabase(), cfg.MongoDB.Collection)
 	esRepo := elasticsearch.NewProductRepository(esClient.GetClient(), cfg.Elasticsearch.Index)
 
-	// Initialize product service
-	productService := service.NewProductService(esRepo, mongoRepo, nil, cfg)
+	// Initialize the embedder used to index products for vector search
+	embedder, err := embedding.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create embedder: %v", err)
+	}
+	dimensions := 0
+	if embedder != nil {
+		dimensions = embedder.Dimensions()
+	}
+	if err := esRepo.EnsureMapping(dimensions); err != nil {
+		log.Fatalf("Failed to set up the Elasticsearch mapping: %v", err)
+	}
+
+	// Initialize product service with the publishing and purge schedules
+	productService := service.NewProductService(esRepo, mongoRepo, nil, embedder, nil, cfg)
+	productService.StartLifecycleSchedule()
+	productService.StartPurgeSchedule()
+
+	// Initialize purchase service
+	coPurchaseRepo := mongodb.NewCoPurchaseRepository(mongoClient.GetDatabase(), cfg.MongoDB.CoPurchaseCollection)
+	purchaseService := service.NewPurchaseService(coPurchaseRepo, mongoRepo, nil, cfg)
 
-	// Initialize event handler
+	// Initialize analytics service
+	searchLogRepo := mongodb.NewSearchLogRepository(mongoClient.GetDatabase(), cfg.MongoDB.SearchLogCollection)
+	searchEventRepo := mongodb.NewSearchEventRepository(mongoClient.GetDatabase(), cfg.MongoDB.SearchEventCollection)
+	analyticsService := service.NewAnalyticsService(searchLogRepo, searchEventRepo, nil, cfg)
+
+	// Initialize event handlers
 	eventHandler := kafka.NewProductEventHandler(productService)
+	purchaseEventHandler := kafka.NewPurchaseEventHandler(purchaseService)
+	analyticsEventHandler := kafka.NewAnalyticsEventHandler(analyticsService)
 
 	// Subscribe to Kafka topics
 	topics := []string{
@@ -69,6 +96,10 @@ func main() {
 		cfg.Kafka.Topic.ProductDeleted,
 		cfg.Kafka.Topic.ProductViewsInc,
 		cfg.Kafka.Topic.ProductBuysInc,
+		cfg.Kafka.Topic.ProductPurchased,
+		cfg.Kafka.Topic.ProductStockChanged,
+		cfg.Kafka.Topic.SearchLogged,
+		cfg.Kafka.Topic.SearchEvent,
 	}
 
 	// Create consumers for each topic
@@ -111,6 +142,22 @@ func main() {
 					if err := eventHandler.OnBuysIncremented(msg.Value); err != nil {
 						log.Printf("Failed to handle product_buys_incremented event: %v", err)
 					}
+				case cfg.Kafka.Topic.ProductPurchased:
+					if err := purchaseEventHandler.OnPurchased(msg.Value); err != nil {
+						log.Printf("Failed to handle product_purchased event: %v", err)
+					}
+				case cfg.Kafka.Topic.ProductStockChanged:
+					if err := eventHandler.OnStockChanged(msg.Value); err != nil {
+						log.Printf("Failed to handle product_stock_changed event: %v", err)
+					}
+				case cfg.Kafka.Topic.SearchLogged:
+					if err := analyticsEventHandler.OnSearchLogged(msg.Value); err != nil {
+						log.Printf("Failed to handle search_logged event: %v", err)
+					}
+				case cfg.Kafka.Topic.SearchEvent:
+					if err := analyticsEventHandler.OnSearchEvent(msg.Value); err != nil {
+						log.Printf("Failed to handle search_event event: %v", err)
+					}
 				}
 			default:
 				// No message available, continue to next consumer
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
@@ -11,9 +13,15 @@ type Config struct {
 		Environment string
 	}
 	MongoDB struct {
-		URI        string `mapstructure:"uri"`
-		Database   string `mapstructure:"database"`
-		Collection string `mapstructure:"collection"`
+		URI                   string `mapstructure:"uri"`
+		Database              string `mapstructure:"database"`
+		Collection            string `mapstructure:"collection"`
+		CoPurchaseCollection  string `mapstructure:"co_purchase_collection"`
+		SearchLogCollection   string `mapstructure:"search_log_collection"`
+		SearchEventCollection string `mapstructure:"search_event_collection"`
+		ExperimentCollection  string `mapstructure:"experiment_collection"`
+		RuleCollection        string `mapstructure:"rule_collection"`
+		AuditCollection       string `mapstructure:"audit_collection"`
 	} `mapstructure:"mongodb"`
 	Elasticsearch struct {
 		Addresses []string `mapstructure:"addresses"`
@@ -25,13 +33,37 @@ type Config struct {
 		Brokers []string `mapstructure:"brokers"`
 		GroupID string   `mapstructure:"group_id"`
 		Topic   struct {
-			ProductCreated  string `mapstructure:"product_created"`
-			ProductUpdated  string `mapstructure:"product_updated"`
-			ProductDeleted  string `mapstructure:"product_deleted"`
-			ProductViewsInc string `mapstructure:"product_views_inc"`
-			ProductBuysInc  string `mapstructure:"product_buys_inc"`
+			ProductCreated      string `mapstructure:"product_created"`
+			ProductUpdated      string `mapstructure:"product_updated"`
+			ProductDeleted      string `mapstructure:"product_deleted"`
+			ProductViewsInc     string `mapstructure:"product_views_inc"`
+			ProductBuysInc      string `mapstructure:"product_buys_inc"`
+			ProductPurchased    string `mapstructure:"product_purchased"`
+			ProductStockChanged string `mapstructure:"product_stock_changed"`
+			SearchLogged        string `mapstructure:"search_logged"`
+			SearchEvent         string `mapstructure:"search_event"`
 		} `mapstructure:"topic"`
 	} `mapstructure:"kafka"`
+	Products struct {
+		// DeletedRetention is how long soft deleted products are kept before
+		// they are purged
+		DeletedRetention time.Duration `mapstructure:"deleted_retention"`
+		// Categories are the allowed product categories; any category is
+		// allowed when empty
+		Categories []string `mapstructure:"categories"`
+		// MaxPrice is the highest allowed product price, in rupiah; any price
+		// is allowed when 0
+		MaxPrice float64 `mapstructure:"max_price"`
+	} `mapstructure:"products"`
+	Embedding struct {
+		// Provider is "hash", "http" or empty to disable vector search
+		Provider   string `mapstructure:"provider"`
+		URL        string `mapstructure:"url"`
+		Model      string `mapstructure:"model"`
+		APIKey     string `mapstructure:"api_key"`
+		Dimensions int    `mapstructure:"dimensions"`
+		TimeoutMs  int    `mapstructure:"timeout_ms"`
+	} `mapstructure:"embedding"`
 	Logging struct {
 		Level  string
 		Format string
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang-ecommerce-search/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultAnalyticsRange = 7 * 24 * time.Hour
+	maxAnalyticsLimit     = 100
+)
+
+type AnalyticsHandler struct {
+	service domain.AnalyticsService
+}
+
+func NewAnalyticsHandler(service domain.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		service: service,
+	}
+}
+
+func (h *AnalyticsHandler) TopQueries(c *gin.Context) {
+	h.report(c, h.service.TopQueries)
+}
+
+func (h *AnalyticsHandler) ZeroResultQueries(c *gin.Context) {
+	h.report(c, h.service.ZeroResultQueries)
+}
+
+func (h *AnalyticsHandler) LowCTRQueries(c *gin.Context) {
+	h.report(c, h.service.LowCTRQueries)
+}
+
+func (h *AnalyticsHandler) RankingStats(c *gin.Context) {
+	query, err := parseAnalyticsQuery(c)
+	if err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	switch query.GroupBy {
+	case domain.GroupByQuery, domain.GroupByRankingProfile:
+	default:
+		respondError(c, domain.Invalidf("invalid group_by: %q", query.GroupBy))
+		return
+	}
+
+	stats, err := h.service.RankingStats(query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func (h *AnalyticsHandler) RecordSearchEvent(c *gin.Context) {
+	var event domain.SearchEvent
+	if err := c.ShouldBindJSON(&event); err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	if err := h.service.RecordSearchEvent(&event); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+func (h *AnalyticsHandler) report(c *gin.Context, fn func(domain.AnalyticsQuery) ([]*domain.QueryStats, error)) {
+	query, err := parseAnalyticsQuery(c)
+	if err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	stats, err := fn(query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// parseAnalyticsQuery reads the from/to range (RFC 3339 or YYYY-MM-DD, defaulting
+// to the last seven days), limit, min_searches and group_by query parameters
+func parseAnalyticsQuery(c *gin.Context) (domain.AnalyticsQuery, error) {
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		t, err := parseTime(value)
+		if err != nil {
+			return domain.AnalyticsQuery{}, fmt.Errorf("invalid to: %q", value)
+		}
+		to = t
+	}
+
+	from := to.Add(-defaultAnalyticsRange)
+	if value := c.Query("from"); value != "" {
+		t, err := parseTime(value)
+		if err != nil {
+			return domain.AnalyticsQuery{}, fmt.Errorf("invalid from: %q", value)
+		}
+		from = t
+	}
+
+	if !from.Before(to) {
+		return domain.AnalyticsQuery{}, fmt.Errorf("from must be before to")
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 {
+		limit = 20
+	}
+	if limit > maxAnalyticsLimit {
+		limit = maxAnalyticsLimit
+	}
+
+	minSearches, err := strconv.Atoi(c.DefaultQuery("min_searches", "10"))
+	if err != nil || minSearches < 1 {
+		minSearches = 10
+	}
+
+	return domain.AnalyticsQuery{
+		From:        from,
+		To:          to,
+		Limit:       limit,
+		MinSearches: minSearches,
+		GroupBy:     c.DefaultQuery("group_by", domain.GroupByQuery),
+	}, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
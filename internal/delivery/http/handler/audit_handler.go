@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-ecommerce-search/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+// auditContext identifies the caller of a product change by the X-Actor
+// header, falling back to X-User-ID, and the request ID
+func auditContext(c *gin.Context) domain.AuditContext {
+	actor := c.GetHeader("X-Actor")
+	if actor == "" {
+		actor = c.GetHeader("X-User-ID")
+	}
+	return domain.AuditContext{Actor: actor, RequestID: requestID(c)}
+}
+
+// History lists the recorded changes to a product, newest first
+func (h *ProductHandler) History(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultHistoryLimit)))
+	if err != nil || limit < 1 {
+		respondError(c, domain.Invalidf("limit must be a positive integer"))
+		return
+	}
+	if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+
+	entries, err := h.service.ProductHistory(c.Param("id"), limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"history": entries})
+}
+
+// Revert restores a product to a revision of its history
+func (h *ProductHandler) Revert(c *gin.Context) {
+	var req struct {
+		Revision int64 `json:"revision" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	product, err := h.service.RevertProduct(c.Param("id"), req.Revision, auditContext(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(product.Version))
+	c.JSON(http.StatusOK, product)
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"golang-ecommerce-search/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag is the entity tag of a product version
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// ifMatchVersion returns the product version of the If-Match header. A
+// missing header or * matches any version and returns 0. An invalid header
+// is reported as a validation error.
+func ifMatchVersion(c *gin.Context) (int64, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	value = strings.TrimPrefix(value, "W/")
+	version, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+	if err != nil || version < 1 {
+		return 0, domain.Invalidf("If-Match must be the ETag of a product version")
+	}
+	return version, nil
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"golang-ecommerce-search/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errorStatus maps the kinds of domain errors to HTTP statuses
+var errorStatus = map[domain.ErrorKind]int{
+	domain.ErrorNotFound:    http.StatusNotFound,
+	domain.ErrorValidation:  http.StatusBadRequest,
+	domain.ErrorConflict:    http.StatusConflict,
+	domain.ErrorUnavailable: http.StatusServiceUnavailable,
+}
+
+// invalidBody reports a request body that could not be decoded, naming the
+// field whose value has the wrong type
+func invalidBody(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return domain.InvalidFields("invalid request body", domain.FieldErrors{
+			{Field: typeErr.Field, Message: "must be of type " + typeErr.Type.String()},
+		})
+	}
+	return domain.Invalid(err)
+}
+
+// respondError reports an error with a JSON body holding its code, message
+// and the request ID. Domain errors are mapped to their status and message;
+// any other error is logged and reported as an internal error without details.
+func respondError(c *gin.Context, err error) {
+	respondErrorDetails(c, err, nil)
+}
+
+// respondErrorDetails reports an error like respondError, adding details
+// such as the position of a syntax error to the body
+func respondErrorDetails(c *gin.Context, err error, details gin.H) {
+	status := http.StatusInternalServerError
+	body := gin.H{
+		"code":       domain.ErrorInternal,
+		"message":    "internal server error",
+		"request_id": requestID(c),
+	}
+
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		status = errorStatus[domainErr.Kind]
+		body["code"] = domainErr.Kind
+		body["message"] = domainErr.Message
+	}
+
+	// Invalid payloads list the reason of each invalid field
+	var fieldErrs domain.FieldErrors
+	if errors.As(err, &fieldErrs) {
+		body["fields"] = fieldErrs
+	}
+
+	// Conflicting updates return the current version so clients can reload
+	var conflict *domain.VersionConflictError
+	if errors.As(err, &conflict) {
+		c.Header("ETag", etag(conflict.Current))
+		body["current_version"] = conflict.Current
+	}
+
+	for key, value := range details {
+		body[key] = value
+	}
+
+	if status >= http.StatusInternalServerError {
+		log.Printf("Request %s %s (%s) failed: %v", c.Request.Method, c.Request.URL.Path, requestID(c), err)
+	}
+	c.JSON(status, gin.H{"error": body})
+}
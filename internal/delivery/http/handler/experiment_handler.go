@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"net/http"
+
+	"golang-ecommerce-search/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExperimentHandler struct {
+	service domain.ExperimentService
+}
+
+func NewExperimentHandler(service domain.ExperimentService) *ExperimentHandler {
+	return &ExperimentHandler{
+		service: service,
+	}
+}
+
+func (h *ExperimentHandler) Create(c *gin.Context) {
+	var experiment domain.Experiment
+	if err := c.ShouldBindJSON(&experiment); err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	if experiment.Status == "" {
+		experiment.Status = domain.ExperimentDraft
+	}
+	if err := experiment.Validate(); err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	if err := h.service.CreateExperiment(&experiment); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, experiment)
+}
+
+func (h *ExperimentHandler) Update(c *gin.Context) {
+	id := c.Param("id")
+	var experiment domain.Experiment
+	if err := c.ShouldBindJSON(&experiment); err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	if err := experiment.Validate(); err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	experiment.ID = id
+	if err := h.service.UpdateExperiment(&experiment); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, experiment)
+}
+
+func (h *ExperimentHandler) Delete(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.service.DeleteExperiment(id); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ExperimentHandler) Get(c *gin.Context) {
+	id := c.Param("id")
+	experiment, err := h.service.GetExperiment(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, experiment)
+}
+
+func (h *ExperimentHandler) List(c *gin.Context) {
+	experiments, err := h.service.ListExperiments()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, experiments)
+}
+
+// Report returns CTR, conversion rate and MRR per variant over the from/to range
+func (h *ExperimentHandler) Report(c *gin.Context) {
+	id := c.Param("id")
+	query, err := parseAnalyticsQuery(c)
+	if err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	stats, err := h.service.Report(id, query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
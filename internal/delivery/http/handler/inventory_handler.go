@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+
+	"golang-ecommerce-search/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InventoryHandler struct {
+	service domain.ProductService
+}
+
+func NewInventoryHandler(service domain.ProductService) *InventoryHandler {
+	return &InventoryHandler{
+		service: service,
+	}
+}
+
+// Reserve decreases the stock, failing with 409 when not enough is available
+func (h *InventoryHandler) Reserve(c *gin.Context) {
+	h.adjust(c, false, h.service.ReserveStock)
+}
+
+// Release returns previously reserved stock
+func (h *InventoryHandler) Release(c *gin.Context) {
+	h.adjust(c, false, h.service.ReleaseStock)
+}
+
+// Set replaces the stock, e.g. after a stock count
+func (h *InventoryHandler) Set(c *gin.Context) {
+	h.adjust(c, true, h.service.SetStock)
+}
+
+func (h *InventoryHandler) adjust(c *gin.Context, allowZero bool, apply func(string, *domain.StockAdjustment, domain.AuditContext) (*domain.StockChange, error)) {
+	var adjustment domain.StockAdjustment
+	if err := c.ShouldBindJSON(&adjustment); err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	if err := adjustment.Validate(allowZero); err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	change, err := apply(c.Param("id"), &adjustment, auditContext(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
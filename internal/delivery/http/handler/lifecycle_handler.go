@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-ecommerce-search/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LifecycleHandler struct {
+	service domain.ProductService
+}
+
+func NewLifecycleHandler(service domain.ProductService) *LifecycleHandler {
+	return &LifecycleHandler{
+		service: service,
+	}
+}
+
+// List lists products of any status for the catalog team, optionally
+// filtered by status. deleted=true lists the soft deleted products.
+func (h *LifecycleHandler) List(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" {
+		if err := domain.ValidateStatus(status); err != nil {
+			respondError(c, domain.Invalid(err))
+			return
+		}
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if err != nil || pageSize < 1 {
+		pageSize = 20
+	}
+
+	products, err := h.service.ListProducts(domain.ProductListParams{
+		Status:   status,
+		Deleted:  c.Query("deleted") == "true",
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, products)
+}
+
+// SetStatus changes the status of a product and its publishing schedule
+func (h *LifecycleHandler) SetStatus(c *gin.Context) {
+	var lifecycle domain.Lifecycle
+	if err := c.ShouldBindJSON(&lifecycle); err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	if err := lifecycle.Validate(); err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	product, err := h.service.SetLifecycle(c.Param("id"), &lifecycle, version, auditContext(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(product.Version))
+	c.JSON(http.StatusOK, product)
+}
+
+// Restore undoes the deletion of a soft deleted product and re-indexes it
+func (h *LifecycleHandler) Restore(c *gin.Context) {
+	product, err := h.service.RestoreProduct(c.Param("id"), auditContext(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
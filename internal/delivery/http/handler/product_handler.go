@@ -1,36 +1,51 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"golang-ecommerce-search/internal/domain"
+	"golang-ecommerce-search/internal/querylang"
 
 	"github.com/gin-gonic/gin"
 )
 
+// maxGroupSize caps the results per group when collapsing or diversifying
+const maxGroupSize = 10
+
 type ProductHandler struct {
-	service domain.ProductService
+	service     domain.ProductService
+	experiments domain.ExperimentService
+	rules       domain.RuleService
+	queries     domain.QueryUnderstandingService
 }
 
-func NewProductHandler(service domain.ProductService) *ProductHandler {
+func NewProductHandler(service domain.ProductService, experiments domain.ExperimentService, rules domain.RuleService, queries domain.QueryUnderstandingService) *ProductHandler {
 	return &ProductHandler{
-		service: service,
+		service:     service,
+		experiments: experiments,
+		rules:       rules,
+		queries:     queries,
 	}
 }
 
 func (h *ProductHandler) Create(c *gin.Context) {
 	var product domain.Product
 	if err := c.ShouldBindJSON(&product); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, invalidBody(err))
 		return
 	}
 
-	if err := h.service.CreateProduct(&product); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.CreateProduct(&product, auditContext(c)); err != nil {
+		respondError(c, err)
 		return
 	}
 
+	c.Header("ETag", etag(product.Version))
 	c.JSON(http.StatusCreated, product)
 }
 
@@ -38,23 +53,84 @@ func (h *ProductHandler) Update(c *gin.Context) {
 	id := c.Param("id")
 	var product domain.Product
 	if err := c.ShouldBindJSON(&product); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, invalidBody(err))
+		return
+	}
+
+	// The If-Match ETag takes precedence over the version in the body
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
+	if version > 0 {
+		product.Version = version
+	}
 
 	product.ID = id
-	if err := h.service.UpdateProduct(&product); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	err = h.service.UpdateProduct(&product, auditContext(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(product.Version))
+	c.JSON(http.StatusOK, product)
+}
+
+// Bulk executes a batch of upserts and deletes, reporting the outcome of
+// each operation. Failed operations do not fail the request.
+func (h *ProductHandler) Bulk(c *gin.Context) {
+	var req struct {
+		Operations []domain.BulkOperation `json:"operations"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+	if len(req.Operations) == 0 || len(req.Operations) > domain.MaxBulkOperations {
+		respondError(c, domain.Invalidf("operations must have between 1 and %d operations", domain.MaxBulkOperations))
+		return
+	}
+
+	result, err := h.service.BulkProducts(req.Operations, auditContext(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Patch applies a JSON Merge Patch to the editable fields of a product
+func (h *ProductHandler) Patch(c *gin.Context) {
+	patch, err := c.GetRawData()
+	if err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	product, err := h.service.PatchProduct(c.Param("id"), patch, version, auditContext(c))
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
+	c.Header("ETag", etag(product.Version))
 	c.JSON(http.StatusOK, product)
 }
 
 func (h *ProductHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
-	if err := h.service.DeleteProduct(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	err := h.service.DeleteProduct(id, auditContext(c))
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -65,18 +141,222 @@ func (h *ProductHandler) Get(c *gin.Context) {
 	id := c.Param("id")
 	product, err := h.service.GetProduct(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
+	c.Header("ETag", etag(product.Version))
 	c.JSON(http.StatusOK, product)
 }
 
 func (h *ProductHandler) Search(c *gin.Context) {
-	query := c.Query("q")
-	categories := c.QueryArray("categories")
-	brands := c.QueryArray("brands")
-	sortBy := c.DefaultQuery("sort_by", "")
+	params, ok := h.searchParams(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.SearchProducts(params)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Explain runs a search like Search and returns the score breakdown of each
+// hit along with the query sent to Elasticsearch. The ranking_profile
+// parameter overrides the profile of the experiment variant.
+func (h *ProductHandler) Explain(c *gin.Context) {
+	params, ok := h.searchParams(c)
+	if !ok {
+		return
+	}
+
+	if name := c.Query("ranking_profile"); name != "" {
+		if _, ok := domain.LookupRankingProfile(name); !ok {
+			respondError(c, domain.Invalidf("unknown ranking profile %q", name))
+			return
+		}
+		params.RankingProfile = name
+	}
+
+	explanation, err := h.service.ExplainSearch(params)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, explanation)
+}
+
+// searchParams builds the parameters of a search request: filters, sorting,
+// the advanced query syntax, the experiment variant, merchandising rules and
+// query interpretation. It responds with 400 and returns false when the
+// request is invalid.
+func (h *ProductHandler) searchParams(c *gin.Context) (domain.SearchParams, bool) {
+	params, err := parseSearchParams(c)
+	if err != nil {
+		respondError(c, domain.Invalid(err))
+		return params, false
+	}
+
+	params.Query = c.Query("q")
+	params.Relax = c.Query("relax") != "false"
+	params.SortBy = c.DefaultQuery("sort_by", "")
+	params.Sort, err = domain.ParseSort(params.SortBy)
+	if err != nil {
+		respondError(c, domain.Invalid(err))
+		return params, false
+	}
+
+	params.Mode = c.DefaultQuery("mode", domain.SearchModeKeyword)
+	if err := domain.ValidateSearchMode(params.Mode); err != nil {
+		respondError(c, domain.Invalid(err))
+		return params, false
+	}
+	if params.Mode != domain.SearchModeKeyword && params.Query == "" {
+		respondError(c, domain.Invalidf("q is required for %s search", params.Mode))
+		return params, false
+	}
+
+	params.Collapse, params.Diversity, err = parseGrouping(c)
+	if err != nil {
+		respondError(c, domain.Invalid(err))
+		return params, false
+	}
+
+	// Parse the advanced query syntax, e.g. brand:apple -refurbished price:<1000
+	switch syntax := c.DefaultQuery("syntax", "simple"); syntax {
+	case "simple":
+	case "advanced":
+		if params.Mode != domain.SearchModeKeyword {
+			respondError(c, domain.Invalidf("the advanced syntax only supports keyword search"))
+			return params, false
+		}
+		if params.Query != "" {
+			params.Expression, err = querylang.Parse(params.Query)
+			if err != nil {
+				var syntaxErr *querylang.SyntaxError
+				if errors.As(err, &syntaxErr) {
+					respondErrorDetails(c, domain.Invalid(err), gin.H{"position": syntaxErr.Pos})
+					return params, false
+				}
+				respondError(c, domain.Invalid(err))
+				return params, false
+			}
+		}
+	default:
+		respondError(c, domain.Invalidf("invalid syntax: %q", syntax))
+		return params, false
+	}
+
+	// Serve the search with the ranking profile of the user's experiment variant
+	assignment, err := h.experiments.Assign(experimentUnitID(c))
+	if err != nil {
+		log.Printf("Failed to assign experiment variant: %v", err)
+	} else if assignment != nil {
+		params.RankingProfile = assignment.Variant.RankingProfile
+		params.ExperimentID = assignment.ExperimentID
+		params.Variant = assignment.Variant.Name
+	}
+
+	// Apply the merchandising rules matching the query
+	if params.Query != "" && params.Expression == nil {
+		params.Rules, err = h.rules.MatchRules(params.Query)
+		if err != nil {
+			log.Printf("Failed to match merchandising rules: %v", err)
+		}
+	}
+
+	// Turn brands, categories and price phrases in the query into filters,
+	// unless the client already applied them and sets interpret=false
+	if params.Query != "" && params.Expression == nil && c.Query("interpret") != "false" {
+		interpretation, err := h.queries.Interpret(params.Query)
+		if err != nil {
+			log.Printf("Failed to interpret query: %v", err)
+		} else if !interpretation.Empty() {
+			interpretation.Apply(&params)
+			if !interpretation.Empty() {
+				params.Interpretation = interpretation
+			}
+		}
+	}
+
+	return params, true
+}
+
+func (h *ProductHandler) Similar(c *gin.Context) {
+	id := c.Param("id")
+	params, err := parseSearchParams(c)
+	if err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	products, err := h.service.SimilarProducts(id, params)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, products)
+}
+
+// experimentUnitID identifies the user, or the session for anonymous users,
+// used to bucket searches into experiment variants
+func experimentUnitID(c *gin.Context) string {
+	if userID := c.GetHeader("X-User-ID"); userID != "" {
+		return "user:" + userID
+	}
+	if sessionID := c.GetHeader("X-Session-ID"); sessionID != "" {
+		return "session:" + sessionID
+	}
+	if sessionID := c.Query("session_id"); sessionID != "" {
+		return "session:" + sessionID
+	}
+	return ""
+}
+
+// parseGrouping reads the collapse or diversify parameters, e.g.
+// collapse=brand&collapse_size=2 or diversify=brand&max_per_group=3
+func parseGrouping(c *gin.Context) (*domain.Collapse, *domain.Diversity, error) {
+	var collapse *domain.Collapse
+	if field := c.Query("collapse"); field != "" {
+		if err := domain.ValidateGroupField(field); err != nil {
+			return nil, nil, err
+		}
+		size, err := strconv.Atoi(c.DefaultQuery("collapse_size", "1"))
+		if err != nil || size < 1 || size > maxGroupSize {
+			return nil, nil, fmt.Errorf("collapse_size must be between 1 and %d", maxGroupSize)
+		}
+		collapse = &domain.Collapse{Field: field, Size: size}
+	}
+
+	var diversity *domain.Diversity
+	if field := c.Query("diversify"); field != "" {
+		if err := domain.ValidateGroupField(field); err != nil {
+			return nil, nil, err
+		}
+		maxPerGroup, err := strconv.Atoi(c.DefaultQuery("max_per_group", "2"))
+		if err != nil || maxPerGroup < 1 || maxPerGroup > maxGroupSize {
+			return nil, nil, fmt.Errorf("max_per_group must be between 1 and %d", maxGroupSize)
+		}
+		diversity = &domain.Diversity{Field: field, MaxPerGroup: maxPerGroup}
+	}
+
+	// Collapsed results are already grouped, so diversifying them again would
+	// re-rank groups instead of products
+	if collapse != nil && diversity != nil {
+		return nil, nil, errors.New("collapse and diversify cannot be combined")
+	}
+
+	return collapse, diversity, nil
+}
+
+// parseSearchParams reads the filter and pagination query parameters shared by
+// the search endpoints
+func parseSearchParams(c *gin.Context) (domain.SearchParams, error) {
 	page := c.DefaultQuery("page", "1")
 	pageSize := c.DefaultQuery("page_size", "10")
 
@@ -91,28 +371,63 @@ func (h *ProductHandler) Search(c *gin.Context) {
 		pageSizeNum = 10
 	}
 
-	params := domain.SearchParams{
-		Query:      query,
-		Categories: categories,
-		Brands:     brands,
-		SortBy:     sortBy,
-		Page:       pageNum,
-		PageSize:   pageSizeNum,
+	minPrice, err := parsePrice(c, "min_price")
+	if err != nil {
+		return domain.SearchParams{}, err
 	}
 
-	products, err := h.service.SearchProducts(params)
+	maxPrice, err := parsePrice(c, "max_price")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return domain.SearchParams{}, err
 	}
 
-	c.JSON(http.StatusOK, products)
+	if minPrice != nil && maxPrice != nil && *minPrice > *maxPrice {
+		return domain.SearchParams{}, fmt.Errorf("min_price must not be greater than max_price")
+	}
+
+	// Variant attributes are passed as variant[size]=42&variant[color]=black
+	var variantAttributes map[string]string
+	for name, value := range c.QueryMap("variant") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || strings.ContainsAny(name, ".$") {
+			return domain.SearchParams{}, fmt.Errorf("invalid variant attribute %q", name)
+		}
+		if variantAttributes == nil {
+			variantAttributes = make(map[string]string)
+		}
+		variantAttributes[name] = strings.TrimSpace(value)
+	}
+
+	return domain.SearchParams{
+		Categories:        c.QueryArray("categories"),
+		Brands:            c.QueryArray("brands"),
+		MinPrice:          minPrice,
+		MaxPrice:          maxPrice,
+		Page:              pageNum,
+		PageSize:          pageSizeNum,
+		VariantAttributes: variantAttributes,
+		InStock:           c.Query("in_stock") == "true",
+	}, nil
+}
+
+// parsePrice parses an optional non-negative price query parameter
+func parsePrice(c *gin.Context, key string) (*float64, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil || price < 0 {
+		return nil, fmt.Errorf("invalid %s: %q", key, value)
+	}
+	return &price, nil
 }
 
 func (h *ProductHandler) IncrementViews(c *gin.Context) {
 	id := c.Param("id")
 	if err := h.service.IncrementViews(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -122,7 +437,7 @@ func (h *ProductHandler) IncrementViews(c *gin.Context) {
 func (h *ProductHandler) IncrementBuys(c *gin.Context) {
 	id := c.Param("id")
 	if err := h.service.IncrementBuys(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
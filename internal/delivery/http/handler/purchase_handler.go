@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-ecommerce-search/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBoughtTogether caps the number of products returned by BoughtTogether
+const maxBoughtTogether = 50
+
+type PurchaseHandler struct {
+	service domain.PurchaseService
+}
+
+func NewPurchaseHandler(service domain.PurchaseService) *PurchaseHandler {
+	return &PurchaseHandler{
+		service: service,
+	}
+}
+
+func (h *PurchaseHandler) RecordPurchase(c *gin.Context) {
+	var order domain.Order
+	if err := c.ShouldBindJSON(&order); err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	if err := h.service.RecordPurchase(&order); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, order)
+}
+
+func (h *PurchaseHandler) BoughtTogether(c *gin.Context) {
+	id := c.Param("id")
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	if limit > maxBoughtTogether {
+		limit = maxBoughtTogether
+	}
+
+	products, err := h.service.BoughtTogether(id, limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, products)
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"golang-ecommerce-search/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+const requestIDKey = "request_id"
+
+// RequestID identifies each request by its X-Request-ID header, generating an
+// ID when missing. The ID is echoed back and included in error responses and
+// audit entries so a failure can be traced through the logs.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader("X-Request-ID")
+		if requestID == "" {
+			requestID = model.NewID().String()
+		}
+		c.Set(requestIDKey, requestID)
+		c.Header("X-Request-ID", requestID)
+		c.Next()
+	}
+}
+
+// requestID returns the ID of the request set by the RequestID middleware
+func requestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
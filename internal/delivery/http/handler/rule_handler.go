@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"net/http"
+
+	"golang-ecommerce-search/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RuleHandler struct {
+	service domain.RuleService
+}
+
+func NewRuleHandler(service domain.RuleService) *RuleHandler {
+	return &RuleHandler{
+		service: service,
+	}
+}
+
+func (h *RuleHandler) Create(c *gin.Context) {
+	var rule domain.Rule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	if err := rule.Validate(); err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	if err := h.service.CreateRule(&rule); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+func (h *RuleHandler) Update(c *gin.Context) {
+	id := c.Param("id")
+	var rule domain.Rule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	if err := rule.Validate(); err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	rule.ID = id
+	if err := h.service.UpdateRule(&rule); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *RuleHandler) Delete(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.service.DeleteRule(id); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *RuleHandler) Get(c *gin.Context) {
+	id := c.Param("id")
+	rule, err := h.service.GetRule(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *RuleHandler) List(c *gin.Context) {
+	rules, err := h.service.ListRules()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// Refresh reloads the rule cache immediately instead of waiting for the
+// periodic refresh
+func (h *RuleHandler) Refresh(c *gin.Context) {
+	if err := h.service.RefreshRules(); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+
+	"golang-ecommerce-search/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VariantHandler struct {
+	service domain.ProductService
+}
+
+func NewVariantHandler(service domain.ProductService) *VariantHandler {
+	return &VariantHandler{
+		service: service,
+	}
+}
+
+func (h *VariantHandler) List(c *gin.Context) {
+	variants, err := h.service.ListVariants(c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, variants)
+}
+
+func (h *VariantHandler) Create(c *gin.Context) {
+	var variant domain.ProductVariant
+	if err := c.ShouldBindJSON(&variant); err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	if err := variant.Validate(); err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	if err := h.service.AddVariant(c.Param("id"), &variant, auditContext(c)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, variant)
+}
+
+func (h *VariantHandler) Update(c *gin.Context) {
+	var variant domain.ProductVariant
+	if err := c.ShouldBindJSON(&variant); err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	// The SKU identifies the variant and cannot be changed
+	variant.SKU = c.Param("sku")
+	if err := variant.Validate(); err != nil {
+		respondError(c, domain.Invalid(err))
+		return
+	}
+
+	if err := h.service.UpdateVariant(c.Param("id"), &variant, auditContext(c)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, variant)
+}
+
+func (h *VariantHandler) Delete(c *gin.Context) {
+	if err := h.service.DeleteVariant(c.Param("id"), c.Param("sku"), auditContext(c)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,35 @@
+package kafka
+
+import (
+	"encoding/json"
+
+	"golang-ecommerce-search/internal/domain"
+)
+
+type AnalyticsEventHandler struct {
+	analyticsService domain.AnalyticsService
+}
+
+func NewAnalyticsEventHandler(analyticsService domain.AnalyticsService) *AnalyticsEventHandler {
+	return &AnalyticsEventHandler{
+		analyticsService: analyticsService,
+	}
+}
+
+func (h *AnalyticsEventHandler) OnSearchLogged(message []byte) error {
+	var searchLog domain.SearchLog
+	if err := json.Unmarshal(message, &searchLog); err != nil {
+		return err
+	}
+
+	return h.analyticsService.OnSearchLogged(&searchLog)
+}
+
+func (h *AnalyticsEventHandler) OnSearchEvent(message []byte) error {
+	var event domain.SearchEvent
+	if err := json.Unmarshal(message, &event); err != nil {
+		return err
+	}
+
+	return h.analyticsService.OnSearchEvent(&event)
+}
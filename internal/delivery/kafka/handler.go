@@ -25,7 +25,17 @@ func (h *ProductEventHandler) OnCreated(message []byte) error {
 	return h.productService.OnCreated(&product)
 }
 
+// OnUpdated handles both update events: the changed fields of a product, or
+// the whole product
 func (h *ProductEventHandler) OnUpdated(message []byte) error {
+	var change domain.ProductChange
+	if err := json.Unmarshal(message, &change); err != nil {
+		return err
+	}
+	if change.Fields != nil {
+		return h.productService.OnChanged(&change)
+	}
+
 	var product domain.Product
 	if err := json.Unmarshal(message, &product); err != nil {
 		return err
@@ -48,3 +58,12 @@ func (h *ProductEventHandler) OnBuysIncremented(message []byte) error {
 	productID := string(message)
 	return h.productService.OnBuysIncremented(productID)
 }
+
+func (h *ProductEventHandler) OnStockChanged(message []byte) error {
+	var change domain.StockChange
+	if err := json.Unmarshal(message, &change); err != nil {
+		return err
+	}
+
+	return h.productService.OnStockChanged(&change)
+}
@@ -0,0 +1,26 @@
+package kafka
+
+import (
+	"encoding/json"
+
+	"golang-ecommerce-search/internal/domain"
+)
+
+type PurchaseEventHandler struct {
+	purchaseService domain.PurchaseService
+}
+
+func NewPurchaseEventHandler(purchaseService domain.PurchaseService) *PurchaseEventHandler {
+	return &PurchaseEventHandler{
+		purchaseService: purchaseService,
+	}
+}
+
+func (h *PurchaseEventHandler) OnPurchased(message []byte) error {
+	var order domain.Order
+	if err := json.Unmarshal(message, &order); err != nil {
+		return err
+	}
+
+	return h.purchaseService.OnPurchased(&order)
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// SearchLog records a single search request for analytics
+type SearchLog struct {
+	ID          string    `json:"id" bson:"_id"`
+	Query       string    `json:"query" bson:"query"`
+	RawQuery    string    `json:"raw_query" bson:"raw_query"`
+	Categories  []string  `json:"categories,omitempty" bson:"categories,omitempty"`
+	Brands      []string  `json:"brands,omitempty" bson:"brands,omitempty"`
+	MinPrice    *float64  `json:"min_price,omitempty" bson:"min_price,omitempty"`
+	MaxPrice    *float64  `json:"max_price,omitempty" bson:"max_price,omitempty"`
+	SortBy      string    `json:"sort_by,omitempty" bson:"sort_by,omitempty"`
+	Page        int       `json:"page" bson:"page"`
+	PageSize    int       `json:"page_size" bson:"page_size"`
+	ResultCount int64     `json:"result_count" bson:"result_count"`
+	LatencyMs   int64     `json:"latency_ms" bson:"latency_ms"`
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+
+	RankingProfile string `json:"ranking_profile" bson:"ranking_profile"`
+	ExperimentID   string `json:"experiment_id,omitempty" bson:"experiment_id,omitempty"`
+	Variant        string `json:"variant,omitempty" bson:"variant,omitempty"`
+	RedirectURL    string `json:"redirect_url,omitempty" bson:"redirect_url,omitempty"`
+	Relaxation     string `json:"relaxation,omitempty" bson:"relaxation,omitempty"`
+
+	// Interaction counters, maintained from search events
+	Clicks             int64 `json:"clicks" bson:"clicks,omitempty"`
+	Conversions        int64 `json:"conversions" bson:"conversions,omitempty"`
+	FirstClickPosition int   `json:"first_click_position" bson:"first_click_position,omitempty"`
+}
+
+type SearchEventType string
+
+const (
+	SearchEventClick      SearchEventType = "click"
+	SearchEventConversion SearchEventType = "conversion"
+)
+
+// SearchEvent is a click on or a conversion of a product shown in a search
+// response. Position is the 1-based rank of the product in the full result list.
+type SearchEvent struct {
+	ID        string          `json:"id" bson:"_id"`
+	SearchID  string          `json:"search_id" bson:"search_id" binding:"required"`
+	ProductID string          `json:"product_id" bson:"product_id" binding:"required"`
+	Position  int             `json:"position" bson:"position" binding:"required,min=1"`
+	Type      SearchEventType `json:"type" bson:"type" binding:"required,oneof=click conversion"`
+	CreatedAt time.Time       `json:"created_at" bson:"created_at"`
+}
+
+// Ranking quality reports can be grouped by query, ranking profile or
+// experiment variant
+const (
+	GroupByQuery          = "query"
+	GroupByRankingProfile = "ranking_profile"
+	GroupByVariant        = "variant"
+)
+
+// RankingStats measures the ranking quality of a group of searches
+type RankingStats struct {
+	Key            string  `json:"key" bson:"_id"`
+	Searches       int64   `json:"searches" bson:"searches"`
+	Clicks         int64   `json:"clicks" bson:"clicks"`
+	Conversions    int64   `json:"conversions" bson:"conversions"`
+	CTR            float64 `json:"ctr" bson:"ctr"`
+	ConversionRate float64 `json:"conversion_rate" bson:"conversion_rate"`
+	MRR            float64 `json:"mrr" bson:"mrr"`
+}
+
+// QueryStats aggregates the search logs of a normalized query
+type QueryStats struct {
+	Query          string  `json:"query" bson:"_id"`
+	Searches       int64   `json:"searches" bson:"searches"`
+	ZeroResults    int64   `json:"zero_results" bson:"zero_results"`
+	AvgResultCount float64 `json:"avg_result_count" bson:"avg_result_count"`
+	Clicks         int64   `json:"clicks" bson:"clicks"`
+	CTR            float64 `json:"ctr" bson:"ctr"`
+}
+
+// AnalyticsQuery selects the time range and size of an analytics report
+type AnalyticsQuery struct {
+	From        time.Time
+	To          time.Time
+	Limit       int
+	MinSearches int
+	GroupBy     string
+	// ExperimentID restricts the report to searches of an experiment
+	ExperimentID string
+}
+
+type AnalyticsService interface {
+	TopQueries(query AnalyticsQuery) ([]*QueryStats, error)
+	ZeroResultQueries(query AnalyticsQuery) ([]*QueryStats, error)
+	LowCTRQueries(query AnalyticsQuery) ([]*QueryStats, error)
+	RankingStats(query AnalyticsQuery) ([]*RankingStats, error)
+	RecordSearchEvent(event *SearchEvent) error
+	OnSearchLogged(log *SearchLog) error
+	OnSearchEvent(event *SearchEvent) error
+}
+
+// NormalizeQuery lowercases a query and collapses its whitespace so that
+// equivalent searches are grouped together
+func NormalizeQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
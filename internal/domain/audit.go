@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Audited product actions
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditRevert  = "revert"
+)
+
+// ErrRevisionNotFound is returned when reverting to a revision that does not exist
+var ErrRevisionNotFound = NewError(ErrorNotFound, "revision not found")
+
+// AuditContext identifies who made a change and the request that made it
+type AuditContext struct {
+	Actor     string
+	RequestID string
+}
+
+// AuditEntry records a change to a product. Snapshot is the product after the
+// change, or before it for deletions, and is what a revert restores.
+type AuditEntry struct {
+	ID        string        `json:"id" bson:"_id,omitempty"`
+	ProductID string        `json:"product_id" bson:"product_id"`
+	Revision  int64         `json:"revision" bson:"revision"`
+	Action    string        `json:"action" bson:"action"`
+	Actor     string        `json:"actor" bson:"actor"`
+	RequestID string        `json:"request_id" bson:"request_id"`
+	Changes   []FieldChange `json:"changes,omitempty" bson:"changes,omitempty"`
+	// RevertedTo is the revision restored by a revert
+	RevertedTo int64     `json:"reverted_to,omitempty" bson:"reverted_to,omitempty"`
+	Snapshot   *Product  `json:"snapshot" bson:"snapshot"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+}
+
+// FieldChange is the old and new value of a changed product field
+type FieldChange struct {
+	Field string      `json:"field" bson:"field"`
+	Old   interface{} `json:"old" bson:"old"`
+	New   interface{} `json:"new" bson:"new"`
+}
+
+// unauditedFields change without an edit and are left out of diffs
+var unauditedFields = map[string]bool{
+	"id":           true,
+	"created_at":   true,
+	"updated_at":   true,
+	"views":        true,
+	"buys":         true,
+	"availability": true,
+	"version":      true,
+}
+
+// DiffProducts lists the fields that differ between two versions of a
+// product, using their JSON names. A nil version has no fields.
+func DiffProducts(before, after *Product) []FieldChange {
+	oldFields := productFields(before)
+	newFields := productFields(after)
+
+	names := make(map[string]bool)
+	for name := range oldFields {
+		names[name] = true
+	}
+	for name := range newFields {
+		names[name] = true
+	}
+
+	var changes []FieldChange
+	for name := range names {
+		if unauditedFields[name] || reflect.DeepEqual(oldFields[name], newFields[name]) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: name, Old: oldFields[name], New: newFields[name]})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func productFields(product *Product) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if product == nil {
+		return fields
+	}
+	data, err := json.Marshal(product)
+	if err != nil {
+		return fields
+	}
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+// Revert restores the editable fields of a product from a snapshot. Variants,
+// stock and status have their own endpoints and are left as they are.
+func (p *Product) Revert(snapshot *Product) {
+	p.setEditableFields(snapshot)
+}
@@ -0,0 +1,61 @@
+package domain
+
+// Bulk operation actions
+const (
+	BulkUpsert = "upsert"
+	BulkDelete = "delete"
+)
+
+// MaxBulkOperations caps the operations of a bulk request
+const MaxBulkOperations = 1000
+
+// Statuses of bulk operation results
+const (
+	BulkCreated = "created"
+	BulkUpdated = "updated"
+	BulkDeleted = "deleted"
+	BulkFailed  = "failed"
+)
+
+// BulkOperation creates, updates or deletes a product. An upsert updates the
+// product with the ID of the product when it exists, only at the version of
+// the product when it has one, and creates it otherwise; a delete takes the ID.
+type BulkOperation struct {
+	Action  string   `json:"action"`
+	ID      string   `json:"id,omitempty"`
+	Product *Product `json:"product,omitempty"`
+}
+
+// ProductID is the ID of the product an operation applies to: the ID of the
+// operation, or else the ID of its product
+func (o *BulkOperation) ProductID() string {
+	if o.ID == "" && o.Product != nil {
+		return o.Product.ID
+	}
+	return o.ID
+}
+
+// BulkItemResult is the outcome of an operation of a bulk request, at the
+// same index as the operation
+type BulkItemResult struct {
+	Index  int    `json:"index"`
+	Action string `json:"action"`
+	ID     string `json:"id,omitempty"`
+	Status string `json:"status"`
+	// Code, Message and Fields describe why a failed operation failed, and
+	// CurrentVersion is the stored version of a product changed concurrently
+	Code           ErrorKind   `json:"code,omitempty"`
+	Message        string      `json:"message,omitempty"`
+	Fields         FieldErrors `json:"fields,omitempty"`
+	CurrentVersion int64       `json:"current_version,omitempty"`
+}
+
+// BulkResult reports the outcome of every operation of a bulk request, so
+// partial failures are visible
+type BulkResult struct {
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Deleted int               `json:"deleted"`
+	Failed  int               `json:"failed"`
+	Items   []*BulkItemResult `json:"items"`
+}
@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"fmt"
+)
+
+// ErrVersionConflict is returned when a product was changed after the version
+// an update is based on
+var ErrVersionConflict = NewError(ErrorConflict, "product was modified by another request")
+
+// VersionConflictError reports the current version of a product an update
+// conflicted with, so the client can reload it
+type VersionConflictError struct {
+	Current int64
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s, current version is %d", ErrVersionConflict, e.Current)
+}
+
+func (e *VersionConflictError) Unwrap() error {
+	return ErrVersionConflict
+}
@@ -0,0 +1,106 @@
+package domain
+
+import "fmt"
+
+// Fields that results can be collapsed or diversified by
+const (
+	GroupFieldBrand    = "brand"
+	GroupFieldCategory = "category"
+)
+
+// Collapse returns one result per value of Field, with its top Size products
+// as a group
+type Collapse struct {
+	Field string
+	Size  int
+}
+
+// ProductGroup is a brand or category of collapsed results with its top products
+type ProductGroup struct {
+	Value    string     `json:"value"`
+	Products []*Product `json:"products"`
+}
+
+// Diversity limits each of the first pages to MaxPerGroup results per value
+// of Field
+type Diversity struct {
+	Field       string
+	MaxPerGroup int
+}
+
+// ValidateGroupField checks that results can be grouped by the field
+func ValidateGroupField(field string) error {
+	switch field {
+	case GroupFieldBrand, GroupFieldCategory:
+		return nil
+	}
+	return fmt.Errorf("cannot group results by %q", field)
+}
+
+// GroupValue returns the value of a product's group field
+func GroupValue(product *Product, field string) string {
+	if field == GroupFieldCategory {
+		return product.Category
+	}
+	return product.Brand
+}
+
+// Diversify re-ranks products so that no group has more than maxPerGroup of
+// the first size products, keeping the relevance order otherwise. Products
+// over the limit are pushed down and only used to fill the page when there
+// are not enough other products. Fixed products, such as pinned ones, are
+// never pushed down but count towards their group.
+func Diversify(products []*Product, field string, maxPerGroup, size int, fixed map[string]bool) []*Product {
+	counts := make(map[string]int)
+	result := make([]*Product, 0, size)
+	var deferred []*Product
+
+	for _, product := range products {
+		if len(result) >= size {
+			break
+		}
+		group := GroupValue(product, field)
+		if fixed[product.ID] || counts[group] < maxPerGroup {
+			counts[group]++
+			result = append(result, product)
+			continue
+		}
+		deferred = append(deferred, product)
+	}
+
+	for _, product := range deferred {
+		if len(result) >= size {
+			break
+		}
+		result = append(result, product)
+	}
+	return result
+}
+
+// DiversifyPage returns the given page of products diversified page by page:
+// each page is diversified from the products left by the pages before it, so
+// paging never repeats or skips a product and every page keeps the group
+// limit. Fixed products only apply to the first page.
+func DiversifyPage(products []*Product, field string, maxPerGroup, page, size int, fixed map[string]bool) []*Product {
+	remaining := products
+	var result []*Product
+	for i := 1; i <= page; i++ {
+		if i > 1 {
+			fixed = nil
+		}
+		result = Diversify(remaining, field, maxPerGroup, size, fixed)
+
+		taken := make(map[*Product]bool, len(result))
+		for _, product := range result {
+			taken[product] = true
+		}
+		left := make([]*Product, 0, len(remaining))
+		for _, product := range remaining {
+			if !taken[product] {
+				left = append(left, product)
+			}
+		}
+		remaining = left
+	}
+	return result
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// Search modes: keyword matching, nearest neighbours of the query embedding,
+// or both with their scores combined
+const (
+	SearchModeKeyword = "keyword"
+	SearchModeVector  = "vector"
+	SearchModeHybrid  = "hybrid"
+)
+
+// ErrVectorSearchDisabled is returned for vector and hybrid searches when no
+// embedding provider is configured
+var ErrVectorSearchDisabled = NewError(ErrorValidation, "vector search is not enabled")
+
+// ValidateSearchMode checks that mode is one of the supported search modes
+func ValidateSearchMode(mode string) error {
+	switch mode {
+	case SearchModeKeyword, SearchModeVector, SearchModeHybrid:
+		return nil
+	}
+	return errors.New("mode must be keyword, vector or hybrid")
+}
+
+// Embedder turns text into a vector so products and queries with a similar
+// meaning are close to each other
+type Embedder interface {
+	Embed(text string) ([]float32, error)
+	// Dimensions is the length of the vectors returned by Embed
+	Dimensions() int
+}
+
+// EmbeddingText is the text of a product that is embedded for vector search
+func EmbeddingText(product *Product) string {
+	parts := []string{product.Name, product.Brand, product.Category, product.Description}
+	if len(product.Tags) > 0 {
+		parts = append(parts, strings.Join(product.Tags, ", "))
+	}
+
+	var text []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			text = append(text, part)
+		}
+	}
+	return strings.Join(text, ". ")
+}
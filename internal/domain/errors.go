@@ -0,0 +1,61 @@
+package domain
+
+import "fmt"
+
+// ErrorKind classifies domain errors so every entry point reports them the
+// same way
+type ErrorKind string
+
+const (
+	// ErrorNotFound is returned for resources that do not exist
+	ErrorNotFound ErrorKind = "not_found"
+	// ErrorValidation is returned for invalid input
+	ErrorValidation ErrorKind = "validation_failed"
+	// ErrorConflict is returned for changes conflicting with the current state
+	ErrorConflict ErrorKind = "conflict"
+	// ErrorUnavailable is returned when a backing store or provider cannot be
+	// reached
+	ErrorUnavailable ErrorKind = "unavailable"
+	// ErrorInternal is reported for unexpected errors, whose details are
+	// only logged
+	ErrorInternal ErrorKind = "internal"
+)
+
+// Error is a domain error of a kind. Message is safe to show to clients,
+// while Err is the underlying cause, if any, and is only logged.
+type Error struct {
+	Kind    ErrorKind
+	Message string
+	Err     error
+}
+
+// NewError returns a domain error without an underlying cause
+func NewError(kind ErrorKind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Invalid marks an error describing invalid input as a validation error
+func Invalid(err error) error {
+	return &Error{Kind: ErrorValidation, Message: err.Error()}
+}
+
+// Invalidf returns a validation error with a formatted message
+func Invalidf(format string, args ...interface{}) error {
+	return &Error{Kind: ErrorValidation, Message: fmt.Sprintf(format, args...)}
+}
+
+// Unavailable wraps the error of a backing store that cannot be reached
+func Unavailable(message string, err error) error {
+	return &Error{Kind: ErrorUnavailable, Message: message, Err: err}
+}
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// ErrExperimentNotFound is returned for experiments that do not exist
+var ErrExperimentNotFound = NewError(ErrorNotFound, "experiment not found")
+
+type ExperimentStatus string
+
+const (
+	ExperimentDraft   ExperimentStatus = "draft"
+	ExperimentRunning ExperimentStatus = "running"
+	ExperimentStopped ExperimentStatus = "stopped"
+)
+
+// Variant is an arm of an experiment. Traffic is the percentage of users
+// assigned to the variant.
+type Variant struct {
+	Name           string `json:"name" bson:"name" binding:"required"`
+	RankingProfile string `json:"ranking_profile" bson:"ranking_profile" binding:"required"`
+	Traffic        int    `json:"traffic" bson:"traffic" binding:"min=0,max=100"`
+}
+
+// Experiment compares ranking profiles on live search traffic
+type Experiment struct {
+	ID          string           `json:"id" bson:"_id,omitempty"`
+	Name        string           `json:"name" bson:"name" binding:"required"`
+	Description string           `json:"description" bson:"description"`
+	Status      ExperimentStatus `json:"status" bson:"status"`
+	Variants    []Variant        `json:"variants" bson:"variants" binding:"required,min=1,dive"`
+	CreatedAt   time.Time        `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate checks the status, variant names, ranking profiles and traffic split
+func (e *Experiment) Validate() error {
+	switch e.Status {
+	case ExperimentDraft, ExperimentRunning, ExperimentStopped:
+	default:
+		return fmt.Errorf("invalid experiment status %q", e.Status)
+	}
+
+	total := 0
+	names := make(map[string]bool, len(e.Variants))
+	for _, v := range e.Variants {
+		if names[v.Name] {
+			return fmt.Errorf("duplicate variant %q", v.Name)
+		}
+		names[v.Name] = true
+
+		if _, ok := LookupRankingProfile(v.RankingProfile); !ok {
+			return fmt.Errorf("unknown ranking profile %q in variant %q", v.RankingProfile, v.Name)
+		}
+		total += v.Traffic
+	}
+
+	if total > 100 {
+		return fmt.Errorf("variant traffic adds up to %d%%, must not exceed 100%%", total)
+	}
+	return nil
+}
+
+// Assign deterministically buckets a user or session into a variant. Units
+// hashed beyond the total traffic of the variants are not in the experiment.
+func (e *Experiment) Assign(unitID string) *Variant {
+	h := fnv.New32a()
+	h.Write([]byte(e.ID + ":" + unitID))
+	bucket := int(h.Sum32() % 100)
+
+	for i := range e.Variants {
+		if bucket < e.Variants[i].Traffic {
+			return &e.Variants[i]
+		}
+		bucket -= e.Variants[i].Traffic
+	}
+	return nil
+}
+
+// Assignment is the experiment variant a search was served with
+type Assignment struct {
+	ExperimentID string
+	Variant      Variant
+}
+
+type ExperimentService interface {
+	CreateExperiment(experiment *Experiment) error
+	UpdateExperiment(experiment *Experiment) error
+	DeleteExperiment(id string) error
+	GetExperiment(id string) (*Experiment, error)
+	ListExperiments() ([]*Experiment, error)
+	Assign(unitID string) (*Assignment, error)
+	Report(id string, query AnalyticsQuery) ([]*RankingStats, error)
+}
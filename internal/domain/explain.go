@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SearchExplanation shows how a search was scored, for relevance tuning
+type SearchExplanation struct {
+	// Request is the query body sent to the search backend
+	Request json.RawMessage `json:"request"`
+	Total   int64           `json:"total"`
+	// RuleIDs are the merchandising rules matching the query
+	RuleIDs []string `json:"rule_ids,omitempty"`
+	// RankingProfile is the ranking the search was scored with
+	RankingProfile string            `json:"ranking_profile"`
+	Hits           []*HitExplanation `json:"hits"`
+}
+
+// HitExplanation breaks down the score of a single result
+type HitExplanation struct {
+	Position int      `json:"position"`
+	Product  *Product `json:"product"`
+	Score    float64  `json:"score"`
+	// TextScore is the relevance of the text match before popularity and boosts
+	TextScore float64 `json:"text_score"`
+	// Functions are the function_score contributions added to the text score
+	Functions []ScoreContribution `json:"functions,omitempty"`
+	// Rules describes the merchandising rule effects on the product
+	Rules []string `json:"rules,omitempty"`
+	// Explanation is the full score explanation returned by the search backend
+	Explanation json.RawMessage `json:"explanation,omitempty"`
+}
+
+// ScoreContribution is the value a scoring function added to the score
+type ScoreContribution struct {
+	Description string  `json:"description"`
+	Value       float64 `json:"value"`
+}
+
+// Describe describes the rule effects applied to a product
+func (e *RuleEffects) Describe(productID string) []string {
+	if e.Empty() {
+		return nil
+	}
+
+	var applied []string
+	for _, pin := range e.Pins {
+		if pin.ProductID == productID {
+			applied = append(applied, fmt.Sprintf("pinned at position %d", pin.Position))
+		}
+	}
+	if weight, ok := e.Boosts[productID]; ok {
+		applied = append(applied, fmt.Sprintf("boosted by %g", weight))
+	}
+	for _, id := range e.Buries {
+		if id == productID {
+			applied = append(applied, fmt.Sprintf("buried with factor %g", e.BuryFactor))
+		}
+	}
+	return applied
+}
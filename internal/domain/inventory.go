@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Availability statuses of a product
+const (
+	AvailabilityInStock    = "in_stock"
+	AvailabilityOutOfStock = "out_of_stock"
+)
+
+// ErrInsufficientStock is returned when a reservation exceeds the available stock
+var ErrInsufficientStock = NewError(ErrorConflict, "insufficient stock")
+
+// StockAdjustment changes the stock of a product, or of one of its variants
+// when SKU is set
+type StockAdjustment struct {
+	SKU string `json:"sku"`
+	// Quantity is the amount reserved or released, or the new stock when set
+	Quantity int64 `json:"quantity"`
+}
+
+// Validate checks the adjustment; reservations and releases need a positive
+// quantity while setting the stock accepts zero
+func (a *StockAdjustment) Validate(allowZero bool) error {
+	if a.Quantity < 0 || (a.Quantity == 0 && !allowZero) {
+		return errors.New("quantity must be positive")
+	}
+	return nil
+}
+
+// StockChange is published whenever the stock of a product changes
+type StockChange struct {
+	ProductID    string    `json:"product_id"`
+	SKU          string    `json:"sku,omitempty"`
+	Stock        int64     `json:"stock"`
+	Availability string    `json:"availability"`
+	ChangedAt    time.Time `json:"changed_at"`
+}
+
+// RefreshAvailability derives the availability of a product from its stock
+// and the stock of its variants
+func (p *Product) RefreshAvailability() {
+	p.Availability = AvailabilityOutOfStock
+	if p.Stock > 0 {
+		p.Availability = AvailabilityInStock
+		return
+	}
+	for _, variant := range p.Variants {
+		if variant.Stock > 0 {
+			p.Availability = AvailabilityInStock
+			return
+		}
+	}
+}
+
+// StockOf returns the stock of the product, or of its variant when sku is set
+func (p *Product) StockOf(sku string) (int64, bool) {
+	if sku == "" {
+		return p.Stock, true
+	}
+	for _, variant := range p.Variants {
+		if variant.SKU == sku {
+			return variant.Stock, true
+		}
+	}
+	return 0, false
+}
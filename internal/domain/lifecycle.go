@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Lifecycle statuses of a product. Only published products are searchable.
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+	StatusArchived  = "archived"
+)
+
+// HiddenStatuses are the statuses of products excluded from search. Products
+// indexed before statuses existed have none and stay searchable.
+var HiddenStatuses = []string{StatusDraft, StatusArchived}
+
+// Lifecycle sets the status of a product and schedules when it is published
+// and unpublished
+type Lifecycle struct {
+	Status      string     `json:"status"`
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	UnpublishAt *time.Time `json:"unpublish_at,omitempty"`
+}
+
+// ValidateStatus checks that status is a lifecycle status
+func ValidateStatus(status string) error {
+	switch status {
+	case StatusDraft, StatusPublished, StatusArchived:
+		return nil
+	}
+	return fmt.Errorf("status must be %s, %s or %s", StatusDraft, StatusPublished, StatusArchived)
+}
+
+// Validate checks the status and that the schedule fits it: a publish date
+// only makes sense for products not yet published
+func (l *Lifecycle) Validate() error {
+	if err := ValidateStatus(l.Status); err != nil {
+		return err
+	}
+	if l.PublishAt != nil && l.Status == StatusPublished {
+		return errors.New("publish_at cannot be set on a published product")
+	}
+	if l.PublishAt != nil && l.UnpublishAt != nil && !l.UnpublishAt.After(*l.PublishAt) {
+		return errors.New("unpublish_at must be after publish_at")
+	}
+	return nil
+}
+
+// Lifecycle returns the status and schedule of the product. Products without
+// a status are published, as before statuses existed, unless they are
+// scheduled to be published later.
+func (p *Product) Lifecycle() Lifecycle {
+	status := p.Status
+	if status == "" {
+		status = StatusPublished
+		if p.PublishAt != nil {
+			status = StatusDraft
+		}
+	}
+	return Lifecycle{Status: status, PublishAt: p.PublishAt, UnpublishAt: p.UnpublishAt}
+}
+
+// SetLifecycle applies a status and schedule to the product
+func (p *Product) SetLifecycle(lifecycle Lifecycle) {
+	p.Status = lifecycle.Status
+	p.PublishAt = lifecycle.PublishAt
+	p.UnpublishAt = lifecycle.UnpublishAt
+}
+
+// ProductListParams filters the products listed by admin endpoints
+type ProductListParams struct {
+	Status string
+	// Deleted lists the soft deleted products instead of the active ones
+	Deleted  bool
+	Page     int
+	PageSize int
+}
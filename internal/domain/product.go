@@ -1,41 +1,152 @@
 package domain
 
-import "time"
+import (
+	"time"
+
+	"golang-ecommerce-search/internal/querylang"
+)
 
 type Product struct {
-	ID          string    `json:"id" bson:"_id,omitempty"`
-	Name        string    `json:"name" bson:"name"`
-	Description string    `json:"description" bson:"description"`
-	Price       float64   `json:"price" bson:"price"`
-	Category    string    `json:"category" bson:"category"`
-	Tags        []string  `json:"tags" bson:"tags"`
-	Brand       string    `json:"brand" bson:"brand"`
-	Views       int64     `json:"views" bson:"views"`
-	Buys        int64     `json:"buys" bson:"buys"`
-	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" bson:"updated_at"`
+	ID          string           `json:"id" bson:"_id,omitempty"`
+	Name        string           `json:"name" bson:"name"`
+	Description string           `json:"description" bson:"description"`
+	Price       float64          `json:"price" bson:"price"`
+	Category    string           `json:"category" bson:"category"`
+	Tags        []string         `json:"tags" bson:"tags"`
+	Brand       string           `json:"brand" bson:"brand"`
+	Views       int64            `json:"views" bson:"views"`
+	Buys        int64            `json:"buys" bson:"buys"`
+	CreatedAt   time.Time        `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at" bson:"updated_at"`
+	Variants    []ProductVariant `json:"variants,omitempty" bson:"variants,omitempty"`
+	// Stock is the quantity available of products without variants, and
+	// Availability is derived from it and the stock of the variants
+	Stock        int64  `json:"stock" bson:"stock"`
+	Availability string `json:"availability" bson:"availability"`
+	// Status is the lifecycle status; drafts are published at PublishAt and
+	// published products are archived at UnpublishAt by the worker
+	Status      string     `json:"status" bson:"status"`
+	PublishAt   *time.Time `json:"publish_at,omitempty" bson:"publish_at,omitempty"`
+	UnpublishAt *time.Time `json:"unpublish_at,omitempty" bson:"unpublish_at,omitempty"`
+	// Version is incremented on every update; updates based on an older
+	// version are rejected so concurrent edits do not overwrite each other
+	Version int64 `json:"version" bson:"version"`
+	// DeletedAt marks a soft deleted product, hidden until restored or purged
+	DeletedAt *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+
+	// Embedding is the vector of the product text, computed by the worker and
+	// only stored in the search index
+	Embedding []float32 `json:"-" bson:"-"`
 }
 
 type SearchParams struct {
 	Query      string
 	Categories []string
 	Brands     []string
+	MinPrice   *float64
+	MaxPrice   *float64
 	SortBy     string
+	Sort       []SortField
 	Page       int
 	PageSize   int
+
+	// RankingProfile names the ranking used for the search, recorded with
+	// the search log so ranking quality can be compared
+	RankingProfile string
+	// ExperimentID and Variant identify the experiment arm the search was served with
+	ExperimentID string
+	Variant      string
+	// Rules are the merchandising rules matching the query
+	Rules *RuleEffects
+	// Interpretation holds the filters extracted from the original query
+	Interpretation *Interpretation
+	// Expression is the parsed advanced query; when set it replaces the
+	// free text search of Query
+	Expression querylang.Node
+
+	// Relax enables zero-result recovery. Operator and Fuzzy override the
+	// text matching of the ranking profile and are set while relaxing.
+	Relax    bool
+	Operator string
+	Fuzzy    bool
+
+	// Collapse limits the results per brand or category, and Diversity
+	// re-ranks the first page to limit the results per brand or category
+	Collapse  *Collapse
+	Diversity *Diversity
+
+	// Mode selects keyword, vector or hybrid search; empty means keyword.
+	// Vector is the embedding of Query used by vector and hybrid searches.
+	Mode   string
+	Vector []float32
+
+	// VariantAttributes only matches products with a variant having all of
+	// the attributes, e.g. {"size": "42"}. InStock only matches products in
+	// stock, and with attribute filters requires the matching variant to be
+	// in stock.
+	VariantAttributes map[string]string
+	InStock           bool
+}
+
+type SearchResult struct {
+	SearchID string     `json:"search_id,omitempty"`
+	Total    int64      `json:"total"`
+	Products []*Product `json:"products"`
+	// Groups holds the top products of each group, in ranking order, when
+	// results are collapsed with more than one product per group. Products
+	// then holds the top product of each group and Total counts the groups.
+	Groups []*ProductGroup `json:"groups,omitempty"`
+	// Redirect is set instead of products when the query leads to a landing page
+	Redirect *Redirect `json:"redirect,omitempty"`
+	// Interpretation reports the filters extracted from the query
+	Interpretation *Interpretation `json:"interpretation,omitempty"`
+	// Relaxation reports how the search was relaxed after returning nothing
+	Relaxation *Relaxation `json:"relaxation,omitempty"`
+}
+
+// Relaxation strategies applied when a search returns no results
+const (
+	RelaxDropFilters     = "drop_filters"
+	RelaxOrMatching      = "or_matching"
+	RelaxFuzzy           = "fuzzy"
+	RelaxCategoryPopular = "category_popular"
+)
+
+// Relaxation describes the relaxation that produced the results
+type Relaxation struct {
+	Strategy       string   `json:"strategy"`
+	DroppedFilters []string `json:"dropped_filters,omitempty"`
 }
 
 type ProductService interface {
-	CreateProduct(product *Product) error
-	UpdateProduct(product *Product) error
-	DeleteProduct(id string) error
+	CreateProduct(product *Product, audit AuditContext) error
+	UpdateProduct(product *Product, audit AuditContext) error
+	PatchProduct(id string, patch []byte, version int64, audit AuditContext) (*Product, error)
+	DeleteProduct(id string, audit AuditContext) error
+	BulkProducts(operations []BulkOperation, audit AuditContext) (*BulkResult, error)
 	GetProduct(id string) (*Product, error)
-	SearchProducts(params SearchParams) ([]*Product, error)
+	SearchProducts(params SearchParams) (*SearchResult, error)
+	ExplainSearch(params SearchParams) (*SearchExplanation, error)
+	SimilarProducts(id string, params SearchParams) ([]*Product, error)
 	IncrementViews(id string) error
 	IncrementBuys(id string) error
+	ListVariants(productID string) ([]ProductVariant, error)
+	AddVariant(productID string, variant *ProductVariant, audit AuditContext) error
+	UpdateVariant(productID string, variant *ProductVariant, audit AuditContext) error
+	DeleteVariant(productID, sku string, audit AuditContext) error
+	ReserveStock(productID string, adjustment *StockAdjustment, audit AuditContext) (*StockChange, error)
+	ReleaseStock(productID string, adjustment *StockAdjustment, audit AuditContext) (*StockChange, error)
+	SetStock(productID string, adjustment *StockAdjustment, audit AuditContext) (*StockChange, error)
+	ListProducts(params ProductListParams) ([]*Product, error)
+	SetLifecycle(productID string, lifecycle *Lifecycle, version int64, audit AuditContext) (*Product, error)
+	RestoreProduct(id string, audit AuditContext) (*Product, error)
+	ProductHistory(id string, limit int) ([]*AuditEntry, error)
+	RevertProduct(id string, revision int64, audit AuditContext) (*Product, error)
 	OnCreated(product *Product) error
 	OnUpdated(product *Product) error
+	OnChanged(change *ProductChange) error
 	OnDeleted(productID string) error
 	OnViewsIncremented(productID string) error
 	OnBuysIncremented(productID string) error
+	OnStockChanged(change *StockChange) error
 }
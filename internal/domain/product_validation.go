@@ -0,0 +1,168 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits of product fields
+const (
+	MaxNameLength        = 200
+	MaxDescriptionLength = 5000
+	MaxBrandLength       = 100
+	MaxCategoryLength    = 100
+	MaxTags              = 20
+	MaxTagLength         = 50
+)
+
+// FieldError describes why a field of a payload is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldErrors lists the invalid fields of a payload
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Field + ": " + fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *FieldErrors) add(field, format string, args ...interface{}) {
+	*e = append(*e, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// InvalidFields returns a validation error listing invalid fields
+func InvalidFields(message string, errs FieldErrors) error {
+	return &Error{Kind: ErrorValidation, Message: message, Err: errs}
+}
+
+// invalidProduct returns the validation error of a product with invalid
+// fields, or nil when all fields are valid
+func invalidProduct(errs FieldErrors) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return InvalidFields("invalid product", errs)
+}
+
+// Validate trims the editable fields of a product and checks them. When
+// categories are given, the category must be one of them and takes its
+// spelling, and with a positive maxPrice the price must not exceed it. All
+// invalid fields are reported together.
+func (p *Product) Validate(categories []string, maxPrice float64) error {
+	var errs FieldErrors
+	p.validateEditable(categories, maxPrice, &errs)
+	return invalidProduct(errs)
+}
+
+// ValidateNew checks a product being created: its editable fields as well as
+// the initial stock, variants and lifecycle. Fields managed by the server are
+// cleared so clients cannot set them.
+func (p *Product) ValidateNew(categories []string, maxPrice float64) error {
+	p.ID = ""
+	p.Views = 0
+	p.Buys = 0
+	p.Version = 0
+	p.DeletedAt = nil
+
+	var errs FieldErrors
+	p.validateEditable(categories, maxPrice, &errs)
+	if p.Stock < 0 {
+		errs.add("stock", "must not be negative")
+	}
+	if err := ValidateVariants(p.Variants); err != nil {
+		errs.add("variants", "%v", err)
+	}
+	lifecycle := p.Lifecycle()
+	if err := lifecycle.Validate(); err != nil {
+		errs.add("status", "%v", err)
+	}
+	return invalidProduct(errs)
+}
+
+func (p *Product) validateEditable(categories []string, maxPrice float64, errs *FieldErrors) {
+	p.Name = strings.TrimSpace(p.Name)
+	p.Description = strings.TrimSpace(p.Description)
+	p.Brand = strings.TrimSpace(p.Brand)
+	p.Category = strings.TrimSpace(p.Category)
+
+	switch {
+	case p.Name == "":
+		errs.add("name", "is required")
+	case utf8.RuneCountInString(p.Name) > MaxNameLength:
+		errs.add("name", "must be at most %d characters", MaxNameLength)
+	}
+	if utf8.RuneCountInString(p.Description) > MaxDescriptionLength {
+		errs.add("description", "must be at most %d characters", MaxDescriptionLength)
+	}
+	if utf8.RuneCountInString(p.Brand) > MaxBrandLength {
+		errs.add("brand", "must be at most %d characters", MaxBrandLength)
+	}
+	switch {
+	case p.Price < 0:
+		errs.add("price", "must not be negative")
+	case maxPrice > 0 && p.Price > maxPrice:
+		errs.add("price", "must be at most %s", strconv.FormatFloat(maxPrice, 'f', -1, 64))
+	}
+	p.validateCategory(categories, errs)
+	p.validateTags(errs)
+}
+
+func (p *Product) validateCategory(categories []string, errs *FieldErrors) {
+	switch {
+	case p.Category == "":
+		errs.add("category", "is required")
+		return
+	case utf8.RuneCountInString(p.Category) > MaxCategoryLength:
+		errs.add("category", "must be at most %d characters", MaxCategoryLength)
+		return
+	case len(categories) == 0:
+		return
+	}
+
+	for _, category := range categories {
+		if strings.EqualFold(p.Category, category) {
+			p.Category = category
+			return
+		}
+	}
+	errs.add("category", "must be one of %s", strings.Join(categories, ", "))
+}
+
+// validateTags trims the tags, drops duplicates regardless of case and
+// checks their number and length
+func (p *Product) validateTags(errs *FieldErrors) {
+	if p.Tags == nil {
+		return
+	}
+
+	tags := make([]string, 0, len(p.Tags))
+	seen := make(map[string]bool, len(p.Tags))
+	for _, tag := range p.Tags {
+		tag = strings.TrimSpace(tag)
+		switch key := strings.ToLower(tag); {
+		case tag == "":
+			errs.add("tags", "must not be empty")
+			return
+		case utf8.RuneCountInString(tag) > MaxTagLength:
+			errs.add("tags", "must be at most %d characters each", MaxTagLength)
+			return
+		case !seen[key]:
+			seen[key] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	if len(tags) > MaxTags {
+		errs.add("tags", "must not have more than %d tags", MaxTags)
+		return
+	}
+	p.Tags = tags
+}
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrProductNotFound = NewError(ErrorNotFound, "product not found")
+	ErrVariantNotFound = NewError(ErrorNotFound, "variant not found")
+	ErrDuplicateSKU    = NewError(ErrorConflict, "a variant with this sku already exists")
+)
+
+// ProductVariant is a purchasable version of a product, such as a shoe size or a
+// phone color, with its own SKU, price and stock
+type ProductVariant struct {
+	SKU string `json:"sku" bson:"sku"`
+	// Attributes distinguish the variant, e.g. {"size": "42", "color": "black"}
+	Attributes map[string]string `json:"attributes" bson:"attributes"`
+	Price      float64           `json:"price" bson:"price"`
+	Stock      int64             `json:"stock" bson:"stock"`
+}
+
+// Validate checks the variant and lower cases its attribute names so filters
+// match regardless of how the attributes were entered
+func (v *ProductVariant) Validate() error {
+	v.SKU = strings.TrimSpace(v.SKU)
+	if v.SKU == "" {
+		return errors.New("sku is required")
+	}
+	if v.Price < 0 {
+		return errors.New("price must not be negative")
+	}
+	if v.Stock < 0 {
+		return errors.New("stock must not be negative")
+	}
+
+	attributes := make(map[string]string, len(v.Attributes))
+	for name, value := range v.Attributes {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			return errors.New("attribute names must not be empty")
+		}
+		if strings.ContainsAny(name, ".$") {
+			return fmt.Errorf("attribute name %q must not contain '.' or '$'", name)
+		}
+		attributes[name] = strings.TrimSpace(value)
+	}
+	v.Attributes = attributes
+	return nil
+}
+
+// ValidateVariants validates the variants of a product and checks that their
+// SKUs are unique
+func ValidateVariants(variants []ProductVariant) error {
+	skus := make(map[string]bool, len(variants))
+	for i := range variants {
+		if err := variants[i].Validate(); err != nil {
+			return fmt.Errorf("variant %d: %w", i+1, err)
+		}
+		if skus[variants[i].SKU] {
+			return fmt.Errorf("variant %d: %w", i+1, ErrDuplicateSKU)
+		}
+		skus[variants[i].SKU] = true
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// Order is a completed purchase containing the products bought together
+type Order struct {
+	ID         string    `json:"order_id"`
+	ProductIDs []string  `json:"product_ids" binding:"required,min=1,max=50"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CoPurchase counts how often a product was bought together with another product
+type CoPurchase struct {
+	ProductID string    `json:"product_id" bson:"product_id"`
+	OtherID   string    `json:"other_id" bson:"other_id"`
+	Count     int64     `json:"count" bson:"count"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// BoughtTogether is a product frequently bought with another product
+type BoughtTogether struct {
+	Product *Product `json:"product"`
+	Count   int64    `json:"count"`
+}
+
+type PurchaseService interface {
+	RecordPurchase(order *Order) error
+	BoughtTogether(productID string, limit int) ([]*BoughtTogether, error)
+	OnPurchased(order *Order) error
+}
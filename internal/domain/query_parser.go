@@ -0,0 +1,278 @@
+package domain
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Interpretation is the structured meaning extracted from a free text query.
+// The UI shows the extracted filters as removable chips.
+type Interpretation struct {
+	OriginalQuery string   `json:"original_query"`
+	Query         string   `json:"query"`
+	Brands        []string `json:"brands,omitempty"`
+	Categories    []string `json:"categories,omitempty"`
+	MinPrice      *float64 `json:"min_price,omitempty"`
+	MaxPrice      *float64 `json:"max_price,omitempty"`
+
+	// parts is the normalized query in order, with the filter each part was
+	// extracted as, so unused filters can be put back into the query
+	parts []queryPart
+}
+
+// Filters a part of the query can be extracted as
+const (
+	filterBrand    = "brand"
+	filterCategory = "category"
+	filterPrice    = "price"
+)
+
+// queryPart is a word or phrase of the query, and the filter it was
+// extracted as or empty when it remains text
+type queryPart struct {
+	text   string
+	filter string
+}
+
+// Empty reports whether nothing was extracted from the query
+func (i *Interpretation) Empty() bool {
+	return len(i.Brands) == 0 && len(i.Categories) == 0 && i.MinPrice == nil && i.MaxPrice == nil
+}
+
+// Apply uses each extracted filter only where the caller did not set it:
+// brands, categories and the price range given explicitly win over those
+// found in the query. Filters that are not used are dropped from the
+// interpretation and their words stay in the query, which is replaced by the
+// remaining text.
+func (i *Interpretation) Apply(params *SearchParams) {
+	unused := make(map[string]bool)
+	if len(params.Brands) == 0 {
+		params.Brands = i.Brands
+	} else if len(i.Brands) > 0 {
+		unused[filterBrand] = true
+		i.Brands = nil
+	}
+	if len(params.Categories) == 0 {
+		params.Categories = i.Categories
+	} else if len(i.Categories) > 0 {
+		unused[filterCategory] = true
+		i.Categories = nil
+	}
+	if params.MinPrice == nil && params.MaxPrice == nil {
+		params.MinPrice = i.MinPrice
+		params.MaxPrice = i.MaxPrice
+	} else if i.MinPrice != nil || i.MaxPrice != nil {
+		unused[filterPrice] = true
+		i.MinPrice = nil
+		i.MaxPrice = nil
+	}
+
+	if len(unused) > 0 && i.parts != nil {
+		var words []string
+		for _, part := range i.parts {
+			if part.filter == "" || unused[part.filter] {
+				words = append(words, part.text)
+			}
+		}
+		i.Query = strings.Join(words, " ")
+	}
+	params.Query = i.Query
+}
+
+// pricePattern matches an amount with an optional "rp" currency and a
+// thousand or million unit
+const pricePattern = `(rp\.?\s*)?(\d+(?:[.,]\d+)*)(?:\s*(k|rb|ribu|jt|juta)\b)?`
+
+var (
+	// Price phrases in English and Indonesian
+	priceBetween = regexp.MustCompile(`\b(?:between|antara)\s+` + pricePattern + `\s*(?:and|dan|sampai|to|-)\s*` + pricePattern)
+	priceUnder   = regexp.MustCompile(`\b(under|below|less than|max|di bawah|dibawah|kurang dari|maks|maksimal)\s+` + pricePattern)
+	priceOver    = regexp.MustCompile(`\b(over|above|more than|min|di atas|diatas|lebih dari|minimal)\s+` + pricePattern)
+
+	// bareKeywords are also part of product names, e.g. "air max 90", so
+	// they only start a price phrase with a currency or unit
+	bareKeywords = map[string]bool{"max": true, "maks": true, "maksimal": true, "min": true, "minimal": true}
+
+	numberSeparator = regexp.MustCompile(`[.,]`)
+
+	// pricePlaceholder marks where a price phrase was taken from the query;
+	// normalized queries never contain it
+	pricePlaceholder = "\x00price"
+)
+
+// QueryParser recognizes known brands, categories and price phrases in queries
+type QueryParser struct {
+	// terms maps normalized brand and category names to their indexed values
+	brands     map[string]string
+	categories map[string]string
+	// maxWords is the longest brand or category name in words
+	maxWords int
+}
+
+// NewQueryParser creates a parser for the given brand and category dictionaries
+func NewQueryParser(brands, categories []string) *QueryParser {
+	p := &QueryParser{
+		brands:     make(map[string]string, len(brands)),
+		categories: make(map[string]string, len(categories)),
+	}
+	add := func(dict map[string]string, values []string) {
+		for _, value := range values {
+			key := NormalizeQuery(value)
+			if key == "" {
+				continue
+			}
+			dict[key] = value
+			if words := len(strings.Fields(key)); words > p.maxWords {
+				p.maxWords = words
+			}
+		}
+	}
+	add(p.brands, brands)
+	add(p.categories, categories)
+	return p
+}
+
+// Parse extracts price ranges first, then brands and categories, preferring
+// the longest matching name. Whatever is not recognized remains as the query.
+func (p *QueryParser) Parse(query string) *Interpretation {
+	interpretation := &Interpretation{OriginalQuery: query}
+	text := NormalizeQuery(query)
+
+	// Price phrases are replaced by a placeholder word so they keep their
+	// place among the other parts of the query
+	var prices []string
+	extractPrice := func(phrase string) {
+		text = strings.Replace(text, phrase, " "+pricePlaceholder+strconv.Itoa(len(prices))+" ", 1)
+		prices = append(prices, phrase)
+	}
+
+	if m := priceBetween.FindStringSubmatch(text); m != nil {
+		low, okLow := parsePrice(m[2], m[3])
+		high, okHigh := parsePrice(m[5], m[6])
+		if okLow && okHigh {
+			if low > high {
+				low, high = high, low
+			}
+			interpretation.MinPrice = &low
+			interpretation.MaxPrice = &high
+			extractPrice(m[0])
+		}
+	}
+	if interpretation.MaxPrice == nil {
+		if phrase, price, ok := findPrice(priceUnder, text); ok {
+			interpretation.MaxPrice = &price
+			extractPrice(phrase)
+		}
+	}
+	if interpretation.MinPrice == nil {
+		if phrase, price, ok := findPrice(priceOver, text); ok {
+			interpretation.MinPrice = &price
+			extractPrice(phrase)
+		}
+	}
+
+	words := strings.Fields(text)
+	var remaining []string
+	for i := 0; i < len(words); {
+		if index, ok := strings.CutPrefix(words[i], pricePlaceholder); ok {
+			n, _ := strconv.Atoi(index)
+			interpretation.parts = append(interpretation.parts, queryPart{text: prices[n], filter: filterPrice})
+			i++
+			continue
+		}
+
+		matched := 0
+		for n := min(p.maxWords, len(words)-i); n > 0; n-- {
+			phrase := strings.Join(words[i:i+n], " ")
+			if brand, ok := p.brands[phrase]; ok {
+				interpretation.Brands = appendUnique(interpretation.Brands, brand)
+				interpretation.parts = append(interpretation.parts, queryPart{text: phrase, filter: filterBrand})
+				matched = n
+				break
+			}
+			if category, ok := p.categories[phrase]; ok {
+				interpretation.Categories = appendUnique(interpretation.Categories, category)
+				interpretation.parts = append(interpretation.parts, queryPart{text: phrase, filter: filterCategory})
+				matched = n
+				break
+			}
+		}
+		if matched == 0 {
+			remaining = append(remaining, words[i])
+			interpretation.parts = append(interpretation.parts, queryPart{text: words[i]})
+			matched = 1
+		}
+		i += matched
+	}
+
+	interpretation.Query = strings.Join(remaining, " ")
+	return interpretation
+}
+
+// findPrice returns the first price phrase of the pattern in text and its
+// amount, skipping bare keywords without a currency or unit
+func findPrice(pattern *regexp.Regexp, text string) (string, float64, bool) {
+	for _, m := range pattern.FindAllStringSubmatch(text, -1) {
+		if bareKeywords[m[1]] && m[2] == "" && m[4] == "" {
+			continue
+		}
+		if price, ok := parsePrice(m[3], m[4]); ok {
+			return m[0], price, true
+		}
+	}
+	return "", 0, false
+}
+
+// parsePrice parses numbers such as "500000", "500.000", "1,5" or "99.99"
+// with an optional thousand (k, rb, ribu) or million (jt, juta) suffix
+func parsePrice(number, suffix string) (float64, bool) {
+	// Separators followed by exactly three digits are thousand separators,
+	// otherwise the last separator is the decimal point
+	parts := numberSeparator.Split(number, -1)
+	intPart := parts[0]
+	decimal := ""
+	for i, part := range parts[1:] {
+		if len(part) == 3 && (i < len(parts)-2 || suffix == "") {
+			intPart += part
+		} else {
+			decimal = part
+		}
+	}
+	if decimal != "" {
+		intPart += "." + decimal
+	}
+
+	value, err := strconv.ParseFloat(intPart, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	switch suffix {
+	case "k", "rb", "ribu":
+		value *= 1000
+	case "jt", "juta":
+		value *= 1000000
+	}
+	return value, true
+}
+
+func appendUnique(values []string, extra ...string) []string {
+	for _, e := range extra {
+		found := false
+		for _, v := range values {
+			if v == e {
+				found = true
+				break
+			}
+		}
+		if !found {
+			values = append(values, e)
+		}
+	}
+	return values
+}
+
+type QueryUnderstandingService interface {
+	Interpret(query string) (*Interpretation, error)
+}
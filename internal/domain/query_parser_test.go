@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func price(value float64) *float64 {
+	return &value
+}
+
+func TestQueryParserParse(t *testing.T) {
+	parser := NewQueryParser([]string{"Nike", "Apple", "New Balance"}, []string{"Shoes", "Phones"})
+
+	tests := []struct {
+		name  string
+		query string
+		want  Interpretation
+	}{
+		{
+			name:  "brand and category",
+			query: "Nike running shoes",
+			want:  Interpretation{Query: "running", Brands: []string{"Nike"}, Categories: []string{"Shoes"}},
+		},
+		{
+			name:  "longest brand name wins",
+			query: "new balance 574",
+			want:  Interpretation{Query: "574", Brands: []string{"New Balance"}},
+		},
+		{
+			name:  "max in a product name is not a price",
+			query: "nike air max 90",
+			want:  Interpretation{Query: "air max 90", Brands: []string{"Nike"}},
+		},
+		{
+			name:  "pro max is not a price",
+			query: "iphone 15 pro max 256",
+			want:  Interpretation{Query: "iphone 15 pro max 256"},
+		},
+		{
+			name:  "min in a product name is not a price",
+			query: "mini cooper min 3",
+			want:  Interpretation{Query: "mini cooper min 3"},
+		},
+		{
+			name:  "max with a unit is a price",
+			query: "sepatu maks 500rb",
+			want:  Interpretation{Query: "sepatu", MaxPrice: price(500000)},
+		},
+		{
+			name:  "max with a currency is a price",
+			query: "shoes max rp 200.000",
+			want:  Interpretation{Query: "", Categories: []string{"Shoes"}, MaxPrice: price(200000)},
+		},
+		{
+			name:  "price after a product name with max",
+			query: "air max 90 under 1,5jt",
+			want:  Interpretation{Query: "air max 90", MaxPrice: price(1500000)},
+		},
+		{
+			name:  "under and over",
+			query: "phones over 100k under 500000",
+			want:  Interpretation{Query: "", Categories: []string{"Phones"}, MinPrice: price(100000), MaxPrice: price(500000)},
+		},
+		{
+			name:  "between in Indonesian",
+			query: "sepatu antara 300rb dan 200rb",
+			want:  Interpretation{Query: "sepatu", MinPrice: price(200000), MaxPrice: price(300000)},
+		},
+		{
+			name:  "unit must be a whole word",
+			query: "shoes under 500 kids",
+			want:  Interpretation{Query: "kids", Categories: []string{"Shoes"}, MaxPrice: price(500)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parser.Parse(tt.query)
+			tt.want.OriginalQuery = tt.query
+			if got.Query != tt.want.Query ||
+				!reflect.DeepEqual(got.Brands, tt.want.Brands) ||
+				!reflect.DeepEqual(got.Categories, tt.want.Categories) ||
+				!reflect.DeepEqual(got.MinPrice, tt.want.MinPrice) ||
+				!reflect.DeepEqual(got.MaxPrice, tt.want.MaxPrice) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		number string
+		suffix string
+		want   float64
+	}{
+		{"500000", "", 500000},
+		{"500.000", "", 500000},
+		{"1.500.000", "", 1500000},
+		{"99.99", "", 99.99},
+		{"1,5", "jt", 1500000},
+		{"1.5", "juta", 1500000},
+		{"500", "rb", 500000},
+		{"250", "k", 250000},
+		{"90", "", 90},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.number+tt.suffix, func(t *testing.T) {
+			got, ok := parsePrice(tt.number, tt.suffix)
+			if !ok || got != tt.want {
+				t.Errorf("parsePrice(%q, %q) = %v, %v, want %v", tt.number, tt.suffix, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpretationApply(t *testing.T) {
+	parser := NewQueryParser([]string{"Nike"}, []string{"Shoes"})
+
+	tests := []struct {
+		name        string
+		query       string
+		params      SearchParams
+		wantQuery   string
+		wantParams  SearchParams
+		wantBrands  []string
+		wantMaxSet  bool
+		wantApplied bool
+	}{
+		{
+			name:        "all filters applied",
+			query:       "nike running shoes under 500rb",
+			wantQuery:   "running",
+			wantParams:  SearchParams{Query: "running", Brands: []string{"Nike"}, Categories: []string{"Shoes"}, MaxPrice: price(500000)},
+			wantBrands:  []string{"Nike"},
+			wantMaxSet:  true,
+			wantApplied: true,
+		},
+		{
+			name:        "brand set by the caller keeps the brand in the query",
+			query:       "nike running shoes",
+			params:      SearchParams{Brands: []string{"Adidas"}},
+			wantQuery:   "nike running",
+			wantParams:  SearchParams{Query: "nike running", Brands: []string{"Adidas"}, Categories: []string{"Shoes"}},
+			wantApplied: true,
+		},
+		{
+			name:        "price set by the caller keeps the price phrase in the query",
+			query:       "running under 500rb socks",
+			params:      SearchParams{MinPrice: price(100)},
+			wantQuery:   "running under 500rb socks",
+			wantParams:  SearchParams{Query: "running under 500rb socks", MinPrice: price(100)},
+			wantApplied: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interpretation := parser.Parse(tt.query)
+			params := tt.params
+			interpretation.Apply(&params)
+
+			if !reflect.DeepEqual(params, tt.wantParams) {
+				t.Errorf("Apply() params = %+v, want %+v", params, tt.wantParams)
+			}
+			if interpretation.Query != tt.wantQuery {
+				t.Errorf("Apply() query = %q, want %q", interpretation.Query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(interpretation.Brands, tt.wantBrands) || (interpretation.MaxPrice != nil) != tt.wantMaxSet {
+				t.Errorf("Apply() interpretation = %+v, want brands %v and max price set %v", interpretation, tt.wantBrands, tt.wantMaxSet)
+			}
+			if !interpretation.Empty() != tt.wantApplied {
+				t.Errorf("Apply() interpretation empty = %v, want %v", interpretation.Empty(), !tt.wantApplied)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package domain
+
+const DefaultRankingProfile = "default"
+
+// RankingProfile configures how the search query is built and scored
+type RankingProfile struct {
+	Name string `json:"name"`
+	// Fields are the multi_match fields with their boosts
+	Fields []string `json:"fields"`
+	// MatchType is the multi_match type, e.g. best_fields or cross_fields
+	MatchType string `json:"match_type"`
+	// Operator is "or" or "and" and controls how query terms are combined
+	Operator string `json:"operator"`
+	// BuysFactor and ViewsFactor weigh the popularity boosts; zero disables them
+	BuysFactor  float64 `json:"buys_factor"`
+	ViewsFactor float64 `json:"views_factor"`
+}
+
+var rankingProfiles = map[string]RankingProfile{
+	DefaultRankingProfile: {
+		Name:        DefaultRankingProfile,
+		Fields:      []string{"name^3", "description^2", "category", "tags"},
+		MatchType:   "best_fields",
+		Operator:    "or",
+		BuysFactor:  0.3,
+		ViewsFactor: 0.1,
+	},
+	"popularity": {
+		Name:        "popularity",
+		Fields:      []string{"name^3", "description^2", "category", "tags"},
+		MatchType:   "best_fields",
+		Operator:    "or",
+		BuysFactor:  1.0,
+		ViewsFactor: 0.3,
+	},
+	"text": {
+		Name:      "text",
+		Fields:    []string{"name^3", "description^2", "category", "tags"},
+		MatchType: "best_fields",
+		Operator:  "or",
+	},
+	"cross_fields": {
+		Name:        "cross_fields",
+		Fields:      []string{"name^3", "description^2", "category", "brand^2", "tags"},
+		MatchType:   "cross_fields",
+		Operator:    "and",
+		BuysFactor:  0.3,
+		ViewsFactor: 0.1,
+	},
+}
+
+// LookupRankingProfile returns the ranking profile with the given name
+func LookupRankingProfile(name string) (RankingProfile, bool) {
+	profile, ok := rankingProfiles[name]
+	return profile, ok
+}
+
+// GetRankingProfile returns the named ranking profile, falling back to the
+// default profile for empty or unknown names
+func GetRankingProfile(name string) RankingProfile {
+	if profile, ok := rankingProfiles[name]; ok {
+		return profile
+	}
+	return rankingProfiles[DefaultRankingProfile]
+}
@@ -0,0 +1,303 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrRuleNotFound is returned for merchandising rules that do not exist
+var ErrRuleNotFound = NewError(ErrorNotFound, "rule not found")
+
+type RuleMatchType string
+
+const (
+	MatchExact    RuleMatchType = "exact"
+	MatchContains RuleMatchType = "contains"
+	MatchPrefix   RuleMatchType = "prefix"
+	MatchRegex    RuleMatchType = "regex"
+)
+
+type RuleActionType string
+
+const (
+	ActionPin            RuleActionType = "pin"
+	ActionBoost          RuleActionType = "boost"
+	ActionBury           RuleActionType = "bury"
+	ActionExclude        RuleActionType = "exclude"
+	ActionFilterCategory RuleActionType = "filter_category"
+	ActionRedirect       RuleActionType = "redirect"
+)
+
+// defaultBuryFactor multiplies the score of buried products when the action
+// does not set its own factor
+const defaultBuryFactor = 0.1
+
+// RuleCondition matches the normalized search query. For regex conditions
+// Query is a case-insensitive regular expression matched against the
+// normalized query.
+type RuleCondition struct {
+	Query     string        `json:"query" bson:"query" binding:"required"`
+	MatchType RuleMatchType `json:"match_type" bson:"match_type"`
+
+	pattern *regexp.Regexp
+}
+
+// RuleAction changes the search results of queries matched by a rule.
+// Position is used by pin, Weight by boost (added to the score) and bury (a
+// score multiplier between 0 and 1, defaulting to 0.1), Categories by
+// filter_category and URL by redirect. A redirect replaces the results with
+// a landing page and must be the only action of its rule.
+type RuleAction struct {
+	Type       RuleActionType `json:"type" bson:"type" binding:"required"`
+	ProductIDs []string       `json:"product_ids,omitempty" bson:"product_ids,omitempty"`
+	Position   int            `json:"position,omitempty" bson:"position,omitempty"`
+	Weight     float64        `json:"weight,omitempty" bson:"weight,omitempty"`
+	Categories []string       `json:"categories,omitempty" bson:"categories,omitempty"`
+	URL        string         `json:"url,omitempty" bson:"url,omitempty"`
+}
+
+// Rule is a merchandising rule applied to searches whose query matches any
+// of its conditions. Rules with a higher priority are applied first.
+type Rule struct {
+	ID         string          `json:"id" bson:"_id,omitempty"`
+	Name       string          `json:"name" bson:"name" binding:"required"`
+	Enabled    bool            `json:"enabled" bson:"enabled"`
+	Priority   int             `json:"priority" bson:"priority"`
+	Conditions []RuleCondition `json:"conditions" bson:"conditions" binding:"required,min=1,dive"`
+	Actions    []RuleAction    `json:"actions" bson:"actions" binding:"required,min=1,dive"`
+	StartsAt   *time.Time      `json:"starts_at,omitempty" bson:"starts_at,omitempty"`
+	EndsAt     *time.Time      `json:"ends_at,omitempty" bson:"ends_at,omitempty"`
+	CreatedAt  time.Time       `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate normalizes the conditions and checks the parameters of every action
+func (r *Rule) Validate() error {
+	for i := range r.Conditions {
+		cond := &r.Conditions[i]
+		if cond.MatchType == "" {
+			cond.MatchType = MatchExact
+		}
+		switch cond.MatchType {
+		case MatchExact, MatchContains, MatchPrefix:
+			cond.Query = NormalizeQuery(cond.Query)
+		case MatchRegex:
+			if _, err := regexp.Compile("(?i)" + cond.Query); err != nil {
+				return fmt.Errorf("invalid regex %q: %v", cond.Query, err)
+			}
+		default:
+			return fmt.Errorf("invalid match type %q", cond.MatchType)
+		}
+	}
+
+	for _, action := range r.Actions {
+		switch action.Type {
+		case ActionPin:
+			if len(action.ProductIDs) == 0 || action.Position < 1 {
+				return fmt.Errorf("pin action requires product_ids and a position of at least 1")
+			}
+		case ActionBoost:
+			if len(action.ProductIDs) == 0 || action.Weight <= 0 {
+				return fmt.Errorf("boost action requires product_ids and a positive weight")
+			}
+		case ActionBury:
+			if len(action.ProductIDs) == 0 || action.Weight < 0 || action.Weight >= 1 {
+				return fmt.Errorf("bury action requires product_ids and a weight between 0 and 1")
+			}
+		case ActionExclude:
+			if len(action.ProductIDs) == 0 {
+				return fmt.Errorf("exclude action requires product_ids")
+			}
+		case ActionFilterCategory:
+			if len(action.Categories) == 0 {
+				return fmt.Errorf("filter_category action requires categories")
+			}
+		case ActionRedirect:
+			if len(r.Actions) > 1 {
+				return fmt.Errorf("redirect action must be the only action of a rule")
+			}
+			if u, err := url.Parse(action.URL); err != nil || action.URL == "" || (u.Scheme == "" && !strings.HasPrefix(u.Path, "/")) {
+				return fmt.Errorf("redirect action requires an absolute URL or path")
+			}
+		default:
+			return fmt.Errorf("invalid action type %q", action.Type)
+		}
+	}
+
+	if r.StartsAt != nil && r.EndsAt != nil && !r.StartsAt.Before(*r.EndsAt) {
+		return fmt.Errorf("starts_at must be before ends_at")
+	}
+	return nil
+}
+
+// Active reports whether the rule is enabled and scheduled at the given time
+func (r *Rule) Active(now time.Time) bool {
+	if !r.Enabled {
+		return false
+	}
+	if r.StartsAt != nil && now.Before(*r.StartsAt) {
+		return false
+	}
+	if r.EndsAt != nil && !now.Before(*r.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// Matches reports whether a normalized query satisfies any of the conditions
+func (r *Rule) Matches(query string) bool {
+	for _, cond := range r.Conditions {
+		switch cond.MatchType {
+		case MatchExact:
+			if query == cond.Query {
+				return true
+			}
+		case MatchContains:
+			if strings.Contains(query, cond.Query) {
+				return true
+			}
+		case MatchPrefix:
+			if strings.HasPrefix(query, cond.Query) {
+				return true
+			}
+		case MatchRegex:
+			if cond.pattern != nil && cond.pattern.MatchString(query) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Compile compiles the regex conditions. Rules must be compiled before they
+// are matched; regex conditions of uncompiled rules never match.
+func (r *Rule) Compile() error {
+	for i := range r.Conditions {
+		cond := &r.Conditions[i]
+		if cond.MatchType != MatchRegex {
+			continue
+		}
+		pattern, err := regexp.Compile("(?i)" + cond.Query)
+		if err != nil {
+			return fmt.Errorf("invalid regex %q in rule %s: %v", cond.Query, r.ID, err)
+		}
+		cond.pattern = pattern
+	}
+	return nil
+}
+
+// Redirect sends a search to a landing page instead of returning products
+type Redirect struct {
+	URL    string `json:"url"`
+	RuleID string `json:"rule_id"`
+}
+
+// Pin places a product at a 1-based position of the first result page
+type Pin struct {
+	ProductID string
+	Position  int
+}
+
+// RuleEffects is the combined effect of the rules matching a search
+type RuleEffects struct {
+	RuleIDs    []string
+	Redirect   *Redirect
+	Pins       []Pin
+	Boosts     map[string]float64
+	Buries     []string
+	BuryFactor float64
+	Excludes   []string
+	Categories []string
+}
+
+// Empty reports whether the effects change the search at all
+func (e *RuleEffects) Empty() bool {
+	return e == nil || len(e.RuleIDs) == 0
+}
+
+// ApplyRules merges the actions of the rules, which must be ordered by
+// priority. Pins and boosts of higher priority rules take precedence.
+func ApplyRules(rules []*Rule) *RuleEffects {
+	effects := &RuleEffects{
+		Boosts:     make(map[string]float64),
+		BuryFactor: defaultBuryFactor,
+	}
+	pinned := make(map[string]bool)
+	positions := make(map[int]bool)
+
+	for _, rule := range rules {
+		effects.RuleIDs = append(effects.RuleIDs, rule.ID)
+		for _, action := range rule.Actions {
+			switch action.Type {
+			case ActionRedirect:
+				if effects.Redirect == nil {
+					effects.Redirect = &Redirect{URL: action.URL, RuleID: rule.ID}
+				}
+			case ActionPin:
+				position := action.Position
+				for _, id := range action.ProductIDs {
+					// A product already pinned keeps its place and frees this one
+					if pinned[id] {
+						continue
+					}
+					for positions[position] {
+						position++
+					}
+					pinned[id] = true
+					positions[position] = true
+					effects.Pins = append(effects.Pins, Pin{ProductID: id, Position: position})
+					position++
+				}
+			case ActionBoost:
+				for _, id := range action.ProductIDs {
+					if _, ok := effects.Boosts[id]; !ok {
+						effects.Boosts[id] = action.Weight
+					}
+				}
+			case ActionBury:
+				effects.Buries = append(effects.Buries, action.ProductIDs...)
+				if action.Weight > 0 && action.Weight < effects.BuryFactor {
+					effects.BuryFactor = action.Weight
+				}
+			case ActionExclude:
+				effects.Excludes = append(effects.Excludes, action.ProductIDs...)
+			case ActionFilterCategory:
+				effects.Categories = append(effects.Categories, action.Categories...)
+			}
+		}
+	}
+
+	// Excluded products are never pinned
+	if len(effects.Excludes) > 0 {
+		excluded := make(map[string]bool, len(effects.Excludes))
+		for _, id := range effects.Excludes {
+			excluded[id] = true
+		}
+		pins := effects.Pins[:0]
+		for _, pin := range effects.Pins {
+			if !excluded[pin.ProductID] {
+				pins = append(pins, pin)
+			}
+		}
+		effects.Pins = pins
+	}
+
+	sort.Slice(effects.Pins, func(i, j int) bool {
+		return effects.Pins[i].Position < effects.Pins[j].Position
+	})
+	return effects
+}
+
+type RuleService interface {
+	CreateRule(rule *Rule) error
+	UpdateRule(rule *Rule) error
+	DeleteRule(id string) error
+	GetRule(id string) (*Rule, error)
+	ListRules() ([]*Rule, error)
+	MatchRules(query string) (*RuleEffects, error)
+	RefreshRules() error
+}
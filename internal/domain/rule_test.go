@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func pinRule(id string, position int, productIDs ...string) *Rule {
+	return &Rule{ID: id, Actions: []RuleAction{{Type: ActionPin, ProductIDs: productIDs, Position: position}}}
+}
+
+func TestApplyRulesPins(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []*Rule
+		want  []Pin
+	}{
+		{
+			name:  "products of a pin take consecutive positions",
+			rules: []*Rule{pinRule("r1", 2, "a", "b")},
+			want:  []Pin{{"a", 2}, {"b", 3}},
+		},
+		{
+			name:  "pins are ordered by position",
+			rules: []*Rule{pinRule("r1", 5, "a"), pinRule("r2", 1, "b")},
+			want:  []Pin{{"b", 1}, {"a", 5}},
+		},
+		{
+			name:  "taken positions move lower priority pins down",
+			rules: []*Rule{pinRule("r1", 1, "a"), pinRule("r2", 1, "b")},
+			want:  []Pin{{"a", 1}, {"b", 2}},
+		},
+		{
+			name:  "duplicate pins keep the higher priority position",
+			rules: []*Rule{pinRule("r1", 1, "a"), pinRule("r2", 3, "a", "b")},
+			want:  []Pin{{"a", 1}, {"b", 3}},
+		},
+		{
+			name:  "duplicate pins within a rule",
+			rules: []*Rule{pinRule("r1", 1, "a", "a", "b")},
+			want:  []Pin{{"a", 1}, {"b", 2}},
+		},
+		{
+			name: "excluded products are not pinned",
+			rules: []*Rule{
+				pinRule("r1", 1, "a", "b"),
+				{ID: "r2", Actions: []RuleAction{{Type: ActionExclude, ProductIDs: []string{"a"}}}},
+			},
+			want: []Pin{{"b", 2}},
+		},
+		{
+			name:  "pins beyond the first page are kept",
+			rules: []*Rule{pinRule("r1", 100, "a")},
+			want:  []Pin{{"a", 100}},
+		},
+		{
+			name:  "no pins",
+			rules: []*Rule{{ID: "r1", Actions: []RuleAction{{Type: ActionBoost, ProductIDs: []string{"a"}, Weight: 2}}}},
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyRules(tt.rules).Pins
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ApplyRules() pins = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyRules(t *testing.T) {
+	rules := []*Rule{
+		{ID: "r1", Actions: []RuleAction{
+			{Type: ActionBoost, ProductIDs: []string{"a"}, Weight: 5},
+			{Type: ActionBury, ProductIDs: []string{"c"}, Weight: 0.5},
+			{Type: ActionFilterCategory, Categories: []string{"Shoes"}},
+		}},
+		{ID: "r2", Actions: []RuleAction{
+			{Type: ActionBoost, ProductIDs: []string{"a", "b"}, Weight: 1},
+			{Type: ActionBury, ProductIDs: []string{"d"}},
+			{Type: ActionExclude, ProductIDs: []string{"e"}},
+		}},
+		{ID: "r3", Actions: []RuleAction{{Type: ActionRedirect, URL: "/sale"}}},
+		{ID: "r4", Actions: []RuleAction{{Type: ActionRedirect, URL: "/other"}}},
+	}
+
+	got := ApplyRules(rules)
+	want := &RuleEffects{
+		RuleIDs:    []string{"r1", "r2", "r3", "r4"},
+		Redirect:   &Redirect{URL: "/sale", RuleID: "r3"},
+		Boosts:     map[string]float64{"a": 5, "b": 1},
+		Buries:     []string{"c", "d"},
+		BuryFactor: 0.1,
+		Excludes:   []string{"e"},
+		Categories: []string{"Shoes"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ApplyRules() = %+v, want %+v", got, want)
+	}
+}
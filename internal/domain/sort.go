@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+// Sort field names understood by the repositories
+const (
+	SortFieldRelevance = "relevance"
+	SortFieldPrice     = "price"
+	SortFieldCreatedAt = "created_at"
+	SortFieldName      = "name"
+	SortFieldViews     = "views"
+	SortFieldBuys      = "buys"
+)
+
+type SortField struct {
+	Field string
+	Order SortOrder
+}
+
+// sortAliases maps the names accepted in sort_by to a field and its default order
+var sortAliases = map[string]SortField{
+	"relevance":  {Field: SortFieldRelevance, Order: SortDesc},
+	"price":      {Field: SortFieldPrice, Order: SortAsc},
+	"newest":     {Field: SortFieldCreatedAt, Order: SortDesc},
+	"created_at": {Field: SortFieldCreatedAt, Order: SortDesc},
+	"name":       {Field: SortFieldName, Order: SortAsc},
+	"views":      {Field: SortFieldViews, Order: SortDesc},
+	"buys":       {Field: SortFieldBuys, Order: SortDesc},
+}
+
+// ParseSort parses a sort expression such as "price:asc,buys:desc".
+// Each comma separated entry is a field name with an optional ":asc" or ":desc"
+// suffix; without a suffix the field's natural order is used.
+func ParseSort(sortBy string) ([]SortField, error) {
+	sortBy = strings.TrimSpace(sortBy)
+	if sortBy == "" {
+		return nil, nil
+	}
+
+	var fields []SortField
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(sortBy, ",") {
+		part = strings.TrimSpace(strings.ToLower(part))
+		if part == "" {
+			continue
+		}
+
+		name, order, hasOrder := strings.Cut(part, ":")
+		field, ok := sortAliases[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown sort field %q", name)
+		}
+
+		if hasOrder {
+			switch SortOrder(order) {
+			case SortAsc, SortDesc:
+				field.Order = SortOrder(order)
+			default:
+				return nil, fmt.Errorf("invalid sort order %q for field %q", order, name)
+			}
+		}
+
+		if seen[field.Field] {
+			return nil, fmt.Errorf("duplicate sort field %q", name)
+		}
+		seen[field.Field] = true
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// editableFields are the product fields changed by updates, patches and
+// reverts. Variants, stock and status have their own endpoints and the other
+// fields are managed by the server.
+var editableFields = map[string]bool{
+	"name":        true,
+	"description": true,
+	"price":       true,
+	"category":    true,
+	"brand":       true,
+	"tags":        true,
+}
+
+// embeddedFields are the product fields the embedding is computed from
+var embeddedFields = []string{"name", "brand", "category", "description", "tags"}
+
+// ProductChange is the update event of a product carrying only the changed
+// fields, by JSON name, so the index is updated partially
+type ProductChange struct {
+	ID     string                 `json:"id"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+// NewProductChange lists the fields of a product that differ between two of
+// its versions with their new values. Removed fields have a nil value.
+func NewProductChange(before, after *Product) *ProductChange {
+	oldFields := productFields(before)
+	newFields := productFields(after)
+
+	change := &ProductChange{ID: after.ID, Fields: map[string]interface{}{}}
+	for name, value := range newFields {
+		if name != "id" && !reflect.DeepEqual(oldFields[name], value) {
+			change.Fields[name] = value
+		}
+	}
+	for name := range oldFields {
+		if _, ok := newFields[name]; !ok {
+			change.Fields[name] = nil
+		}
+	}
+	return change
+}
+
+// AffectsEmbedding reports whether the change requires the embedding of the
+// product to be computed again
+func (c *ProductChange) AffectsEmbedding() bool {
+	for _, name := range embeddedFields {
+		if _, ok := c.Fields[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyMergePatch applies a JSON Merge Patch (RFC 7386) to the editable
+// fields of a product: fields in the patch are replaced and null removes
+// them. A version in the patch is the version the patch is based on. None of
+// the editable fields are objects, so values are replaced as a whole.
+func (p *Product) ApplyMergePatch(patch []byte) error {
+	var changes map[string]interface{}
+	if err := json.Unmarshal(patch, &changes); err != nil || changes == nil {
+		return Invalidf("invalid merge patch: the patch must be a JSON object")
+	}
+
+	fields := productFields(p)
+	for name, value := range changes {
+		switch {
+		case name == "version":
+			version, ok := value.(float64)
+			if !ok || version < 1 || version != float64(int64(version)) {
+				return Invalidf("invalid merge patch: version must be a positive integer")
+			}
+			p.Version = int64(version)
+		case !editableFields[name]:
+			return Invalidf("invalid merge patch: %s cannot be patched", name)
+		case value == nil:
+			delete(fields, name)
+		default:
+			fields[name] = value
+		}
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	var patched Product
+	if err := json.Unmarshal(data, &patched); err != nil {
+		return Invalidf("invalid merge patch: %v", err)
+	}
+	p.setEditableFields(&patched)
+	return nil
+}
+
+// setEditableFields copies the editable fields of another version of the product
+func (p *Product) setEditableFields(from *Product) {
+	p.Name = from.Name
+	p.Description = from.Description
+	p.Price = from.Price
+	p.Category = from.Category
+	p.Brand = from.Brand
+	p.Tags = from.Tags
+}
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func patchableProduct() *Product {
+	return &Product{
+		ID:          "p1",
+		Name:        "Running Shoe",
+		Description: "Light trainers",
+		Price:       120,
+		Category:    "Shoes",
+		Brand:       "Nike",
+		Tags:        []string{"running", "sport"},
+		Stock:       7,
+		Version:     3,
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  func(p *Product)
+	}{
+		{
+			name:  "fields in the patch are replaced",
+			patch: `{"name": "Trail Shoe", "price": 99.5}`,
+			want: func(p *Product) {
+				p.Name = "Trail Shoe"
+				p.Price = 99.5
+			},
+		},
+		{
+			name:  "null deletes a field",
+			patch: `{"description": null, "tags": null}`,
+			want: func(p *Product) {
+				p.Description = ""
+				p.Tags = nil
+			},
+		},
+		{
+			name:  "arrays are replaced, not merged",
+			patch: `{"tags": ["trail"]}`,
+			want: func(p *Product) {
+				p.Tags = []string{"trail"}
+			},
+		},
+		{
+			name:  "empty array clears the array",
+			patch: `{"tags": []}`,
+			want: func(p *Product) {
+				p.Tags = []string{}
+			},
+		},
+		{
+			name:  "version is the version the patch is based on",
+			patch: `{"version": 2, "brand": "Adidas"}`,
+			want: func(p *Product) {
+				p.Version = 2
+				p.Brand = "Adidas"
+			},
+		},
+		{
+			name:  "empty patch changes nothing",
+			patch: `{}`,
+			want:  func(p *Product) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := patchableProduct()
+			if err := got.ApplyMergePatch([]byte(tt.patch)); err != nil {
+				t.Fatalf("ApplyMergePatch(%s) returned error: %v", tt.patch, err)
+			}
+			want := patchableProduct()
+			tt.want(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ApplyMergePatch(%s) = %+v, want %+v", tt.patch, got, want)
+			}
+		})
+	}
+}
+
+func TestApplyMergePatchErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		wantMsg string
+	}{
+		{"not an object", `["name"]`, "invalid merge patch: the patch must be a JSON object"},
+		{"null patch", `null`, "invalid merge patch: the patch must be a JSON object"},
+		{"immutable field", `{"id": "p2"}`, "invalid merge patch: id cannot be patched"},
+		{"server managed field", `{"views": 10}`, "invalid merge patch: views cannot be patched"},
+		{"nested object of an immutable field", `{"variants": {"sku": "X1"}}`, "invalid merge patch: variants cannot be patched"},
+		{"nested object for a scalar field", `{"brand": {"name": "Nike"}}`, ""},
+		{"invalid version", `{"version": 1.5}`, "invalid merge patch: version must be a positive integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := patchableProduct()
+			err := product.ApplyMergePatch([]byte(tt.patch))
+
+			var domainErr *Error
+			if !errors.As(err, &domainErr) || domainErr.Kind != ErrorValidation {
+				t.Fatalf("ApplyMergePatch(%s) error = %v, want a validation error", tt.patch, err)
+			}
+			if tt.wantMsg != "" && domainErr.Message != tt.wantMsg {
+				t.Errorf("ApplyMergePatch(%s) message = %q, want %q", tt.patch, domainErr.Message, tt.wantMsg)
+			}
+			if !reflect.DeepEqual(product, patchableProduct()) {
+				t.Errorf("ApplyMergePatch(%s) changed the product to %+v", tt.patch, product)
+			}
+		})
+	}
+}
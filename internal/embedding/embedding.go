@@ -0,0 +1,44 @@
+package embedding
+
+import (
+	"fmt"
+	"time"
+
+	"golang-ecommerce-search/internal/config"
+	"golang-ecommerce-search/internal/domain"
+)
+
+// Embedding providers selectable in the configuration
+const (
+	ProviderHash = "hash"
+	ProviderHTTP = "http"
+)
+
+const defaultTimeout = 5 * time.Second
+
+// New creates the embedder selected by the configuration. It returns nil when
+// no provider is configured, which disables vector search.
+func New(cfg *config.Config) (domain.Embedder, error) {
+	embedding := cfg.Embedding
+	if embedding.Provider == "" {
+		return nil, nil
+	}
+	if embedding.Dimensions <= 0 {
+		return nil, fmt.Errorf("embedding dimensions must be positive")
+	}
+
+	switch embedding.Provider {
+	case ProviderHash:
+		return NewHashEmbedder(embedding.Dimensions), nil
+	case ProviderHTTP:
+		if embedding.URL == "" {
+			return nil, fmt.Errorf("embedding url is required for the http provider")
+		}
+		timeout := defaultTimeout
+		if embedding.TimeoutMs > 0 {
+			timeout = time.Duration(embedding.TimeoutMs) * time.Millisecond
+		}
+		return NewHTTPEmbedder(embedding.URL, embedding.Model, embedding.APIKey, embedding.Dimensions, timeout), nil
+	}
+	return nil, fmt.Errorf("unknown embedding provider %q", embedding.Provider)
+}
@@ -0,0 +1,132 @@
+package embedding
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func norm(vector []float32) float64 {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	return math.Sqrt(sum)
+}
+
+func TestHashEmbedder(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"single word", "coffee"},
+		{"sentence", "Something to keep coffee warm"},
+		{"mixed case and punctuation", "Thermos, 500ml!"},
+	}
+
+	embedder := NewHashEmbedder(64)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, err := embedder.Embed(tt.text)
+			if err != nil {
+				t.Fatalf("Embed(%q) returned error: %v", tt.text, err)
+			}
+			second, _ := NewHashEmbedder(64).Embed(tt.text)
+			if !reflect.DeepEqual(first, second) {
+				t.Errorf("Embed(%q) is not deterministic", tt.text)
+			}
+			if len(first) != 64 {
+				t.Errorf("Embed(%q) has %d dimensions, want 64", tt.text, len(first))
+			}
+			if n := norm(first); math.Abs(n-1) > 1e-5 {
+				t.Errorf("Embed(%q) has norm %v, want 1", tt.text, n)
+			}
+		})
+	}
+}
+
+func TestHashEmbedderZeroVector(t *testing.T) {
+	for _, text := range []string{"", "   ", "!?"} {
+		vector, err := NewHashEmbedder(8).Embed(text)
+		if err != nil {
+			t.Fatalf("Embed(%q) returned error: %v", text, err)
+		}
+		want := []float32{1, 0, 0, 0, 0, 0, 0, 0}
+		if !reflect.DeepEqual(vector, want) {
+			t.Errorf("Embed(%q) = %v, want %v", text, vector, want)
+		}
+	}
+}
+
+func TestHashEmbedderSimilarity(t *testing.T) {
+	embedder := NewHashEmbedder(256)
+	dot := func(a, b string) float64 {
+		va, _ := embedder.Embed(a)
+		vb, _ := embedder.Embed(b)
+		var sum float64
+		for i := range va {
+			sum += float64(va[i]) * float64(vb[i])
+		}
+		return sum
+	}
+
+	if related, unrelated := dot("warm coffee", "warmer coffee"), dot("warm coffee", "running shoes"); related <= unrelated {
+		t.Errorf("similarity of related texts %v is not above unrelated texts %v", related, unrelated)
+	}
+}
+
+func TestHTTPEmbedder(t *testing.T) {
+	tests := []struct {
+		name      string
+		embedding []float32
+		status    int
+		wantErr   string
+	}{
+		{"matching dimensions", []float32{0.1, 0.2, 0.3}, http.StatusOK, ""},
+		{"too few dimensions", []float32{0.1, 0.2}, http.StatusOK, "embedding has 2 dimensions, expected 3"},
+		{"too many dimensions", []float32{0.1, 0.2, 0.3, 0.4}, http.StatusOK, "embedding has 4 dimensions, expected 3"},
+		{"service error", nil, http.StatusInternalServerError, "embedding service returned 500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req struct {
+					Model string   `json:"model"`
+					Input []string `json:"input"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "test-model" || len(req.Input) != 1 {
+					t.Errorf("unexpected request %+v: %v", req, err)
+				}
+				if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+					t.Errorf("Authorization = %q, want Bearer secret", got)
+				}
+				w.WriteHeader(tt.status)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"data": []map[string]interface{}{{"embedding": tt.embedding}},
+				})
+			}))
+			defer server.Close()
+
+			embedder := NewHTTPEmbedder(server.URL, "test-model", "secret", 3, time.Second)
+			vector, err := embedder.Embed("coffee")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Embed() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Embed() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(vector, tt.embedding) {
+				t.Errorf("Embed() = %v, want %v", vector, tt.embedding)
+			}
+		})
+	}
+}
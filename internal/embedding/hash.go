@@ -0,0 +1,78 @@
+// Package embedding provides the embedding providers used for vector search.
+package embedding
+
+import (
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"golang-ecommerce-search/internal/domain"
+)
+
+// hashEmbedder is a deterministic local embedder that hashes word and
+// character trigram features into a fixed number of dimensions. It needs no
+// external service, which makes it suitable for tests and development, but it
+// only captures lexical similarity.
+type hashEmbedder struct {
+	dimensions int
+}
+
+// NewHashEmbedder creates a deterministic local embedder
+func NewHashEmbedder(dimensions int) domain.Embedder {
+	return &hashEmbedder{dimensions: dimensions}
+}
+
+func (e *hashEmbedder) Dimensions() int {
+	return e.dimensions
+}
+
+func (e *hashEmbedder) Embed(text string) ([]float32, error) {
+	vector := make([]float32, e.dimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	for _, word := range words {
+		e.add(vector, "w:"+word, 1)
+		// Trigrams make related word forms such as "warm" and "warmer" similar
+		padded := []rune("^" + word + "$")
+		for i := 0; i+3 <= len(padded); i++ {
+			e.add(vector, "t:"+string(padded[i:i+3]), 0.5)
+		}
+	}
+
+	normalize(vector)
+	return vector, nil
+}
+
+// add hashes a feature to a dimension and a sign, so unrelated features
+// cancel out rather than accumulate
+func (e *hashEmbedder) add(vector []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+
+	if sum&1 == 1 {
+		weight = -weight
+	}
+	vector[(sum>>1)%uint64(len(vector))] += weight
+}
+
+// normalize scales a vector to unit length; cosine similarity rejects zero
+// vectors, so an empty vector gets a single non-zero component
+func normalize(vector []float32) {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		vector[0] = 1
+		return
+	}
+
+	norm = math.Sqrt(norm)
+	for i, v := range vector {
+		vector[i] = float32(float64(v) / norm)
+	}
+}
@@ -0,0 +1,85 @@
+package embedding
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"golang-ecommerce-search/internal/domain"
+)
+
+// httpEmbedder calls an embedding service with an OpenAI compatible API:
+// it posts {"model": ..., "input": [...]} and reads {"data": [{"embedding": [...]}]}
+type httpEmbedder struct {
+	client     *http.Client
+	url        string
+	model      string
+	apiKey     string
+	dimensions int
+}
+
+// NewHTTPEmbedder creates an embedder backed by an embedding service
+func NewHTTPEmbedder(url, model, apiKey string, dimensions int, timeout time.Duration) domain.Embedder {
+	return &httpEmbedder{
+		client:     &http.Client{Timeout: timeout},
+		url:        url,
+		model:      model,
+		apiKey:     apiKey,
+		dimensions: dimensions,
+	}
+}
+
+func (e *httpEmbedder) Dimensions() int {
+	return e.dimensions
+}
+
+func (e *httpEmbedder) Embed(text string) ([]float32, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"model": e.model,
+		"input": []string{text},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	res, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return nil, fmt.Errorf("embedding service returned %d: %s", res.StatusCode, message)
+	}
+
+	var result struct {
+		Data []struct {
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding response: %w", err)
+	}
+	if len(result.Data) == 0 {
+		return nil, fmt.Errorf("embedding service returned no embeddings")
+	}
+
+	vector := result.Data[0].Embedding
+	if len(vector) != e.dimensions {
+		return nil, fmt.Errorf("embedding has %d dimensions, expected %d", len(vector), e.dimensions)
+	}
+	return vector, nil
+}
@@ -0,0 +1,93 @@
+// Package evaluation measures search relevance offline against judgment lists,
+// so ranking changes can be compared before they reach shoppers.
+package evaluation
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang-ecommerce-search/internal/domain"
+)
+
+// MaxGrade is the highest relevance grade of a judgment
+const MaxGrade = 3
+
+// Judgment grades the relevance of a product for a query, from 0 (irrelevant)
+// to MaxGrade (perfect match)
+type Judgment struct {
+	Query     string
+	ProductID string
+	Grade     int
+}
+
+// JudgmentList holds the graded products of each query, in file order
+type JudgmentList struct {
+	Queries []string
+	Grades  map[string]map[string]int
+}
+
+// ReadJudgments reads a CSV judgment list with the columns query, product_id
+// and grade. A header row and lines starting with # are skipped. Queries are
+// normalized so judgments match the way searches are logged.
+func ReadJudgments(r io.Reader) (*JudgmentList, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	list := &JudgmentList{Grades: make(map[string]map[string]int)}
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if first && strings.EqualFold(record[0], "query") {
+			continue
+		}
+
+		judgment, err := parseJudgment(record)
+		if err != nil {
+			row, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %d: %w", row, err)
+		}
+
+		grades, ok := list.Grades[judgment.Query]
+		if !ok {
+			grades = make(map[string]int)
+			list.Grades[judgment.Query] = grades
+			list.Queries = append(list.Queries, judgment.Query)
+		}
+		grades[judgment.ProductID] = judgment.Grade
+	}
+
+	if len(list.Queries) == 0 {
+		return nil, errors.New("judgment list is empty")
+	}
+	return list, nil
+}
+
+func parseJudgment(record []string) (Judgment, error) {
+	query := domain.NormalizeQuery(record[0])
+	if query == "" {
+		return Judgment{}, errors.New("query is required")
+	}
+
+	productID := strings.TrimSpace(record[1])
+	if productID == "" {
+		return Judgment{}, errors.New("product_id is required")
+	}
+
+	grade, err := strconv.Atoi(strings.TrimSpace(record[2]))
+	if err != nil || grade < 0 || grade > MaxGrade {
+		return Judgment{}, fmt.Errorf("grade must be an integer between 0 and %d, got %q", MaxGrade, record[2])
+	}
+
+	return Judgment{Query: query, ProductID: productID, Grade: grade}, nil
+}
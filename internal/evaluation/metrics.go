@@ -0,0 +1,97 @@
+package evaluation
+
+import (
+	"math"
+	"sort"
+)
+
+// QueryMetrics are the relevance metrics of a single query's results
+type QueryMetrics struct {
+	Query string
+	// NDCG is the normalized discounted cumulative gain of the top k results
+	NDCG float64
+	// Precision is the share of the top k results graded relevant
+	Precision float64
+	// ReciprocalRank is 1/rank of the first relevant result, 0 if none
+	ReciprocalRank float64
+}
+
+// Report holds the metrics of every query of a judgment list and their means
+type Report struct {
+	Profile string
+	K       int
+	Queries []QueryMetrics
+	Mean    QueryMetrics
+}
+
+// Evaluate computes the metrics of ranked product IDs against the graded
+// products of a query. Products without a judgment count as irrelevant.
+func Evaluate(query string, ranked []string, grades map[string]int, k int) QueryMetrics {
+	if len(ranked) > k {
+		ranked = ranked[:k]
+	}
+
+	metrics := QueryMetrics{Query: query}
+	var dcg float64
+	var relevant int
+	for i, id := range ranked {
+		grade := grades[id]
+		dcg += gain(grade, i)
+		if grade > 0 {
+			relevant++
+			if metrics.ReciprocalRank == 0 {
+				metrics.ReciprocalRank = 1 / float64(i+1)
+			}
+		}
+	}
+
+	if idcg := idealDCG(grades, k); idcg > 0 {
+		metrics.NDCG = dcg / idcg
+	}
+	if k > 0 {
+		metrics.Precision = float64(relevant) / float64(k)
+	}
+	return metrics
+}
+
+// NewReport builds a report from per query metrics, averaging them over all queries
+func NewReport(profile string, k int, queries []QueryMetrics) *Report {
+	report := &Report{Profile: profile, K: k, Queries: queries, Mean: QueryMetrics{Query: "overall"}}
+	if len(queries) == 0 {
+		return report
+	}
+
+	for _, metrics := range queries {
+		report.Mean.NDCG += metrics.NDCG
+		report.Mean.Precision += metrics.Precision
+		report.Mean.ReciprocalRank += metrics.ReciprocalRank
+	}
+	n := float64(len(queries))
+	report.Mean.NDCG /= n
+	report.Mean.Precision /= n
+	report.Mean.ReciprocalRank /= n
+	return report
+}
+
+// gain is the discounted gain of a grade at a 0-based rank
+func gain(grade, rank int) float64 {
+	return (math.Pow(2, float64(grade)) - 1) / math.Log2(float64(rank+2))
+}
+
+// idealDCG is the DCG of the best possible ranking of the graded products
+func idealDCG(grades map[string]int, k int) float64 {
+	ideal := make([]int, 0, len(grades))
+	for _, grade := range grades {
+		ideal = append(ideal, grade)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(ideal)))
+
+	var idcg float64
+	for i, grade := range ideal {
+		if i >= k {
+			break
+		}
+		idcg += gain(grade, i)
+	}
+	return idcg
+}
@@ -0,0 +1,130 @@
+package evaluation
+
+import (
+	"math"
+	"testing"
+)
+
+const tolerance = 1e-6
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranked []string
+		grades map[string]int
+		k      int
+		want   QueryMetrics
+	}{
+		{
+			name:   "ideal ranking",
+			ranked: []string{"a", "b", "c"},
+			grades: map[string]int{"a": 3, "b": 2, "c": 1},
+			k:      3,
+			want:   QueryMetrics{NDCG: 1, Precision: 1, ReciprocalRank: 1},
+		},
+		{
+			// DCG = 1/log2(2) + 3/log2(3) + 7/log2(4) = 6.392789
+			// IDCG = 7/log2(2) + 3/log2(3) + 1/log2(4) = 9.392789
+			name:   "reversed ranking",
+			ranked: []string{"c", "b", "a"},
+			grades: map[string]int{"a": 3, "b": 2, "c": 1},
+			k:      3,
+			want:   QueryMetrics{NDCG: 0.680606, Precision: 1, ReciprocalRank: 1},
+		},
+		{
+			// DCG = 1/log2(4) = 0.5, IDCG = 1/log2(2) = 1
+			name:   "first relevant result at rank 3",
+			ranked: []string{"x", "y", "a", "z"},
+			grades: map[string]int{"a": 1},
+			k:      4,
+			want:   QueryMetrics{NDCG: 0.5, Precision: 0.25, ReciprocalRank: 1.0 / 3},
+		},
+		{
+			// DCG = 1, IDCG = 1 + 1/log2(3) = 1.630930; precision divides by k
+			name:   "k larger than the results",
+			ranked: []string{"a"},
+			grades: map[string]int{"a": 1, "b": 1},
+			k:      5,
+			want:   QueryMetrics{NDCG: 0.613147, Precision: 0.2, ReciprocalRank: 1},
+		},
+		{
+			name:   "results beyond k are ignored",
+			ranked: []string{"x", "a"},
+			grades: map[string]int{"a": 1},
+			k:      1,
+			want:   QueryMetrics{},
+		},
+		{
+			name:   "unjudged results are irrelevant",
+			ranked: []string{"x", "y"},
+			grades: map[string]int{"a": 2},
+			k:      2,
+			want:   QueryMetrics{},
+		},
+		{
+			name:   "empty judgments",
+			ranked: []string{"a", "b"},
+			grades: map[string]int{},
+			k:      3,
+			want:   QueryMetrics{},
+		},
+		{
+			name:   "no results",
+			ranked: nil,
+			grades: map[string]int{"a": 2},
+			k:      3,
+			want:   QueryMetrics{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Evaluate("shoes", tt.ranked, tt.grades, tt.k)
+			tt.want.Query = "shoes"
+			assertMetrics(t, got, tt.want)
+		})
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		queries []QueryMetrics
+		want    QueryMetrics
+	}{
+		{
+			name:    "no queries",
+			queries: nil,
+			want:    QueryMetrics{Query: "overall"},
+		},
+		{
+			name: "means over all queries",
+			queries: []QueryMetrics{
+				{Query: "a", NDCG: 1, Precision: 0.5, ReciprocalRank: 1},
+				{Query: "b", NDCG: 0.5, Precision: 0, ReciprocalRank: 0.5},
+				{Query: "c"},
+			},
+			want: QueryMetrics{Query: "overall", NDCG: 0.5, Precision: 1.0 / 6, ReciprocalRank: 0.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewReport("default", 10, tt.queries)
+			if report.Profile != "default" || report.K != 10 || len(report.Queries) != len(tt.queries) {
+				t.Errorf("NewReport() = %s@%d with %d queries, want default@10 with %d", report.Profile, report.K, len(report.Queries), len(tt.queries))
+			}
+			assertMetrics(t, report.Mean, tt.want)
+		})
+	}
+}
+
+func assertMetrics(t *testing.T, got, want QueryMetrics) {
+	t.Helper()
+	if got.Query != want.Query ||
+		math.Abs(got.NDCG-want.NDCG) > tolerance ||
+		math.Abs(got.Precision-want.Precision) > tolerance ||
+		math.Abs(got.ReciprocalRank-want.ReciprocalRank) > tolerance {
+		t.Errorf("metrics = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,97 @@
+// Package querylang implements the advanced search syntax used by power users
+// and internal tools, e.g.
+//
+//	brand:apple category:"Home & Kitchen" -refurbished price:<1000
+//
+// Queries are parsed into an AST that the repositories compile into their own
+// query languages, so raw query strings never reach the search backends.
+package querylang
+
+// Node is a node of the query AST
+type Node interface {
+	node()
+}
+
+// And matches when all children match
+type And struct {
+	Children []Node
+}
+
+// Or matches when any child matches
+type Or struct {
+	Children []Node
+}
+
+// Not matches when the child does not match
+type Not struct {
+	Child Node
+}
+
+// Term is free text matched against the searchable fields. Phrase terms were
+// quoted and must match as a whole.
+type Term struct {
+	Text   string
+	Phrase bool
+}
+
+// Match is text matched against a single field, e.g. brand:apple
+type Match struct {
+	Field  string
+	Text   string
+	Phrase bool
+}
+
+type Operator string
+
+const (
+	OpEq  Operator = "="
+	OpLt  Operator = "<"
+	OpLte Operator = "<="
+	OpGt  Operator = ">"
+	OpGte Operator = ">="
+)
+
+// Compare compares a numeric field with a value, e.g. price:<1000
+type Compare struct {
+	Field string
+	Op    Operator
+	Value float64
+}
+
+// Range matches a numeric field between two inclusive bounds, e.g. price:10..100
+type Range struct {
+	Field string
+	From  float64
+	To    float64
+}
+
+func (*And) node()     {}
+func (*Or) node()      {}
+func (*Not) node()     {}
+func (*Term) node()    {}
+func (*Match) node()   {}
+func (*Compare) node() {}
+func (*Range) node()   {}
+
+type fieldKind int
+
+const (
+	textField fieldKind = iota
+	numericField
+)
+
+// fields maps the field qualifiers to the product fields they search
+var fields = map[string]struct {
+	name string
+	kind fieldKind
+}{
+	"name":        {"name", textField},
+	"description": {"description", textField},
+	"brand":       {"brand", textField},
+	"category":    {"category", textField},
+	"tag":         {"tags", textField},
+	"tags":        {"tags", textField},
+	"price":       {"price", numericField},
+	"views":       {"views", numericField},
+	"buys":        {"buys", numericField},
+}
@@ -0,0 +1,336 @@
+package querylang
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// maxDepth limits the nesting of groups and negations
+	maxDepth = 10
+	// maxClauses limits the number of terms and field qualifiers
+	maxClauses = 50
+)
+
+// SyntaxError describes a problem with the query at a 1-based character position
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s", e.Pos, e.Msg)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokQuoted
+	tokField
+	tokMinus
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// Parse parses a query into its AST. Terms separated by whitespace are
+// combined with AND; OR, NOT, "-" and parentheses are also supported.
+func Parse(query string) (Node, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, &SyntaxError{Pos: 1, Msg: "empty query"}
+	}
+
+	node, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %q", tok.text)}
+	}
+	return node, nil
+}
+
+func lex(query string) ([]token, error) {
+	runes := []rune(query)
+	var tokens []token
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: pos})
+			i++
+		case r == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && !isNumberStart(runes[i+1]):
+			tokens = append(tokens, token{kind: tokMinus, text: "-", pos: pos})
+			i++
+		case r == '"':
+			text, next, err := lexQuoted(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokQuoted, text: text, pos: pos})
+			i = next
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(`()":`, runes[i]) {
+				i++
+			}
+			if i < len(runes) && runes[i] == ':' {
+				if i == start {
+					return nil, &SyntaxError{Pos: pos, Msg: "missing field name before ':'"}
+				}
+				tokens = append(tokens, token{kind: tokField, text: string(runes[start:i]), pos: pos})
+				i++
+				if i >= len(runes) || unicode.IsSpace(runes[i]) || runes[i] == ')' {
+					return nil, &SyntaxError{Pos: i + 1, Msg: fmt.Sprintf("missing value for field %q", string(runes[start:i-1]))}
+				}
+				continue
+			}
+			if i == start {
+				return nil, &SyntaxError{Pos: pos, Msg: fmt.Sprintf("unexpected %q", string(r))}
+			}
+			tokens = append(tokens, token{kind: tokWord, text: string(runes[start:i]), pos: pos})
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF, text: "end of query", pos: len(runes) + 1}), nil
+}
+
+func lexQuoted(runes []rune, start int) (string, int, error) {
+	var sb strings.Builder
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				sb.WriteRune(runes[i])
+			}
+		case '"':
+			if strings.TrimSpace(sb.String()) == "" {
+				return "", 0, &SyntaxError{Pos: start + 1, Msg: "empty quoted phrase"}
+			}
+			return sb.String(), i + 1, nil
+		default:
+			sb.WriteRune(runes[i])
+		}
+	}
+	return "", 0, &SyntaxError{Pos: start + 1, Msg: "unterminated quoted phrase"}
+}
+
+func isNumberStart(r rune) bool {
+	return unicode.IsDigit(r) || r == '.'
+}
+
+type parser struct {
+	tokens  []token
+	pos     int
+	clauses int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func isKeyword(tok token, keyword string) bool {
+	return tok.kind == tokWord && tok.text == keyword
+}
+
+func (p *parser) parseOr(depth int) (Node, error) {
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	children := []Node{left}
+	for isKeyword(p.peek(), "OR") {
+		p.next()
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, right)
+	}
+
+	if len(children) == 1 {
+		return left, nil
+	}
+	return &Or{Children: children}, nil
+}
+
+func (p *parser) parseAnd(depth int) (Node, error) {
+	var children []Node
+	for {
+		tok := p.peek()
+		if tok.kind == tokEOF || tok.kind == tokRParen || isKeyword(tok, "OR") {
+			break
+		}
+		if isKeyword(tok, "AND") {
+			p.next()
+			if next := p.peek(); next.kind == tokEOF || next.kind == tokRParen || isKeyword(next, "OR") || isKeyword(next, "AND") {
+				return nil, &SyntaxError{Pos: next.pos, Msg: fmt.Sprintf("expected a term after AND, found %q", next.text)}
+			}
+			continue
+		}
+
+		child, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+
+	switch len(children) {
+	case 0:
+		tok := p.peek()
+		return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("expected a term, found %q", tok.text)}
+	case 1:
+		return children[0], nil
+	}
+	return &And{Children: children}, nil
+}
+
+func (p *parser) parseUnary(depth int) (Node, error) {
+	tok := p.peek()
+	if tok.kind == tokMinus || isKeyword(tok, "NOT") {
+		if depth >= maxDepth {
+			return nil, &SyntaxError{Pos: tok.pos, Msg: "query is nested too deeply"}
+		}
+		p.next()
+		if next := p.peek(); next.kind == tokEOF || next.kind == tokRParen || isKeyword(next, "OR") || isKeyword(next, "AND") {
+			return nil, &SyntaxError{Pos: next.pos, Msg: fmt.Sprintf("expected a term after %q, found %q", tok.text, next.text)}
+		}
+		child, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Child: child}, nil
+	}
+	return p.parsePrimary(depth)
+}
+
+func (p *parser) parsePrimary(depth int) (Node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokLParen:
+		if depth >= maxDepth {
+			return nil, &SyntaxError{Pos: tok.pos, Msg: "query is nested too deeply"}
+		}
+		node, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, &SyntaxError{Pos: closing.pos, Msg: fmt.Sprintf("expected ')' to close '(' at position %d, found %q", tok.pos, closing.text)}
+		}
+		return node, nil
+	case tokWord:
+		if err := p.countClause(tok); err != nil {
+			return nil, err
+		}
+		return &Term{Text: tok.text}, nil
+	case tokQuoted:
+		if err := p.countClause(tok); err != nil {
+			return nil, err
+		}
+		return &Term{Text: tok.text, Phrase: true}, nil
+	case tokField:
+		if err := p.countClause(tok); err != nil {
+			return nil, err
+		}
+		return p.parseField(tok)
+	}
+	return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %q", tok.text)}
+}
+
+func (p *parser) countClause(tok token) error {
+	p.clauses++
+	if p.clauses > maxClauses {
+		return &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("query has more than %d terms", maxClauses)}
+	}
+	return nil
+}
+
+// parseField parses the value of a field qualifier
+func (p *parser) parseField(fieldTok token) (Node, error) {
+	field, ok := fields[strings.ToLower(fieldTok.text)]
+	if !ok {
+		return nil, &SyntaxError{Pos: fieldTok.pos, Msg: fmt.Sprintf("unknown field %q", fieldTok.text)}
+	}
+
+	valueTok := p.next()
+	if valueTok.kind != tokWord && valueTok.kind != tokQuoted {
+		return nil, &SyntaxError{Pos: valueTok.pos, Msg: fmt.Sprintf("missing value for field %q", fieldTok.text)}
+	}
+
+	if field.kind == textField {
+		return &Match{Field: field.name, Text: valueTok.text, Phrase: valueTok.kind == tokQuoted}, nil
+	}
+
+	value := valueTok.text
+	if from, to, ok := strings.Cut(value, ".."); ok {
+		fromValue, err := parseNumber(from, valueTok)
+		if err != nil {
+			return nil, err
+		}
+		toValue, err := parseNumber(to, valueTok)
+		if err != nil {
+			return nil, err
+		}
+		if fromValue > toValue {
+			return nil, &SyntaxError{Pos: valueTok.pos, Msg: fmt.Sprintf("range %q is empty", value)}
+		}
+		return &Range{Field: field.name, From: fromValue, To: toValue}, nil
+	}
+
+	op := OpEq
+	for _, candidate := range []Operator{OpLte, OpGte, OpLt, OpGt, OpEq} {
+		if strings.HasPrefix(value, string(candidate)) {
+			op = candidate
+			value = strings.TrimPrefix(value, string(candidate))
+			break
+		}
+	}
+
+	number, err := parseNumber(value, valueTok)
+	if err != nil {
+		return nil, err
+	}
+	return &Compare{Field: field.name, Op: op, Value: number}, nil
+}
+
+func parseNumber(value string, tok token) (float64, error) {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("expected a number, found %q", tok.text)}
+	}
+	return number, nil
+}
@@ -0,0 +1,169 @@
+package querylang
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  Node
+	}{
+		{
+			name:  "single term",
+			query: "shoes",
+			want:  &Term{Text: "shoes"},
+		},
+		{
+			name:  "terms are combined with AND",
+			query: "red shoes",
+			want:  &And{Children: []Node{&Term{Text: "red"}, &Term{Text: "shoes"}}},
+		},
+		{
+			name:  "quoted phrase",
+			query: `"running shoes"`,
+			want:  &Term{Text: "running shoes", Phrase: true},
+		},
+		{
+			name:  "escaped quote in phrase",
+			query: `"say \"hi\""`,
+			want:  &Term{Text: `say "hi"`, Phrase: true},
+		},
+		{
+			name:  "field qualifiers and negation",
+			query: `brand:apple category:"Home & Kitchen" -refurbished price:<1000`,
+			want: &And{Children: []Node{
+				&Match{Field: "brand", Text: "apple"},
+				&Match{Field: "category", Text: "Home & Kitchen", Phrase: true},
+				&Not{Child: &Term{Text: "refurbished"}},
+				&Compare{Field: "price", Op: OpLt, Value: 1000},
+			}},
+		},
+		{
+			name:  "field names are case insensitive",
+			query: "Tag:sale",
+			want:  &Match{Field: "tags", Text: "sale"},
+		},
+		{
+			name:  "numeric range",
+			query: "price:10..100",
+			want:  &Range{Field: "price", From: 10, To: 100},
+		},
+		{
+			name:  "comparison operators",
+			query: "views:>=5 buys:3",
+			want: &And{Children: []Node{
+				&Compare{Field: "views", Op: OpGte, Value: 5},
+				&Compare{Field: "buys", Op: OpEq, Value: 3},
+			}},
+		},
+		{
+			name:  "minus before a number is part of the term",
+			query: "-5",
+			want:  &Term{Text: "-5"},
+		},
+		{
+			name:  "AND binds tighter than OR",
+			query: "a b OR c",
+			want: &Or{Children: []Node{
+				&And{Children: []Node{&Term{Text: "a"}, &Term{Text: "b"}}},
+				&Term{Text: "c"},
+			}},
+		},
+		{
+			name:  "AND binds tighter than OR on the right",
+			query: "a OR b c",
+			want: &Or{Children: []Node{
+				&Term{Text: "a"},
+				&And{Children: []Node{&Term{Text: "b"}, &Term{Text: "c"}}},
+			}},
+		},
+		{
+			name:  "explicit AND",
+			query: "a AND b OR c",
+			want: &Or{Children: []Node{
+				&And{Children: []Node{&Term{Text: "a"}, &Term{Text: "b"}}},
+				&Term{Text: "c"},
+			}},
+		},
+		{
+			name:  "NOT binds tighter than OR",
+			query: "NOT a OR b",
+			want: &Or{Children: []Node{
+				&Not{Child: &Term{Text: "a"}},
+				&Term{Text: "b"},
+			}},
+		},
+		{
+			name:  "parentheses group",
+			query: "(a OR b) c",
+			want: &And{Children: []Node{
+				&Or{Children: []Node{&Term{Text: "a"}, &Term{Text: "b"}}},
+				&Term{Text: "c"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned error: %v", tt.query, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %#v, want %#v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantPos int
+		wantMsg string
+	}{
+		{"empty query", "", 1, "empty query"},
+		{"blank query", "   ", 1, "empty query"},
+		{"unterminated phrase", `shoes "open`, 7, "unterminated quoted phrase"},
+		{"empty phrase", `""`, 1, "empty quoted phrase"},
+		{"dangling OR", "a OR", 5, `expected a term, found "end of query"`},
+		{"term missing after AND", "a AND OR b", 7, `expected a term after AND, found "OR"`},
+		{"term missing after NOT", "NOT", 4, `expected a term after "NOT", found "end of query"`},
+		{"unclosed group", "(a b", 5, `expected ')' to close '(' at position 1, found "end of query"`},
+		{"unopened group", "a )", 3, `unexpected ")"`},
+		{"unknown field", "color:red", 1, `unknown field "color"`},
+		{"missing field value", "brand:", 7, `missing value for field "brand"`},
+		{"missing field name", ":x", 1, "missing field name before ':'"},
+		{"not a number", "price:abc", 7, `expected a number, found "abc"`},
+		{"empty range", "price:100..10", 7, `range "100..10" is empty`},
+		{"nested too deeply", strings.Repeat("(", 11) + "a" + strings.Repeat(")", 11), 11, "query is nested too deeply"},
+		{"too many terms", strings.Repeat("a ", 51), 101, "query has more than 50 terms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse(%q) error = %v, want a *SyntaxError", tt.query, err)
+			}
+			if syntaxErr.Pos != tt.wantPos || syntaxErr.Msg != tt.wantMsg {
+				t.Errorf("Parse(%q) error = %d %q, want %d %q", tt.query, syntaxErr.Pos, syntaxErr.Msg, tt.wantPos, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestSyntaxErrorMessage(t *testing.T) {
+	err := &SyntaxError{Pos: 7, Msg: `unknown field "color"`}
+	want := `syntax error at position 7: unknown field "color"`
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
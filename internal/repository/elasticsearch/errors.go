@@ -0,0 +1,27 @@
+package elasticsearch
+
+import (
+	"fmt"
+	"net/http"
+
+	"golang-ecommerce-search/internal/domain"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+const unavailableMessage = "the search engine is unavailable"
+
+// transportError reports a request that could not reach Elasticsearch
+func transportError(err error) error {
+	return domain.Unavailable(unavailableMessage, err)
+}
+
+// responseError reports a request Elasticsearch failed. Server side failures
+// and rejections under load mean the search engine is unavailable.
+func responseError(request string, res *esapi.Response) error {
+	err := fmt.Errorf("%s failed: %s", request, res.String())
+	if res.StatusCode >= http.StatusInternalServerError || res.StatusCode == http.StatusTooManyRequests {
+		return domain.Unavailable(unavailableMessage, err)
+	}
+	return err
+}
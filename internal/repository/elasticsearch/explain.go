@@ -0,0 +1,159 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"golang-ecommerce-search/internal/domain"
+)
+
+// explanation is a node of the score explanation returned for explain requests
+type explanation struct {
+	Value       float64        `json:"value"`
+	Description string         `json:"description"`
+	Details     []*explanation `json:"details"`
+}
+
+// Explain runs the search with explain enabled and breaks down the score of
+// each hit into its text relevance and function_score contributions
+func (r *productRepository) Explain(params domain.SearchParams) (*domain.SearchExplanation, error) {
+	ctx := context.Background()
+	body := buildSearchBody(params)
+	body["explain"] = true
+
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.client.Search(
+		r.client.Search.WithContext(ctx),
+		r.client.Search.WithIndex(r.index),
+		r.client.Search.WithBody(bytes.NewReader(bodyBytes)),
+	)
+	if err != nil {
+		return nil, transportError(err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, responseError("explain request", res)
+	}
+
+	var result struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Score       float64         `json:"_score"`
+				Source      domain.Product  `json:"_source"`
+				Explanation json.RawMessage `json:"_explanation"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	from := (params.Page - 1) * params.PageSize
+	if from < 0 {
+		from = 0
+	}
+
+	explained := &domain.SearchExplanation{
+		Request: bodyBytes,
+		Total:   result.Hits.Total.Value,
+		Hits:    make([]*domain.HitExplanation, 0, len(result.Hits.Hits)),
+	}
+	if params.Rules != nil {
+		explained.RuleIDs = params.Rules.RuleIDs
+	}
+
+	for i, hit := range result.Hits.Hits {
+		source := hit.Source
+		hitExplanation := &domain.HitExplanation{
+			Position:    from + i + 1,
+			Product:     &source,
+			Score:       hit.Score,
+			TextScore:   hit.Score,
+			Rules:       params.Rules.Describe(source.ID),
+			Explanation: hit.Explanation,
+		}
+
+		var tree explanation
+		if err := json.Unmarshal(hit.Explanation, &tree); err == nil {
+			hitExplanation.TextScore, hitExplanation.Functions = breakDownScore(&tree, hit.Score)
+		}
+		explained.Hits = append(explained.Hits, hitExplanation)
+	}
+
+	return explained, nil
+}
+
+// breakDownScore finds the function_score part of an explanation and returns
+// the score of the wrapped query and the contribution of each function. When
+// no function matched, the whole score is text relevance.
+func breakDownScore(root *explanation, score float64) (float64, []domain.ScoreContribution) {
+	functions, ancestors := findFunctions(root, nil)
+	if functions == nil {
+		return score, nil
+	}
+
+	var contributions []domain.ScoreContribution
+	for _, function := range functions.Details {
+		contributions = append(contributions, domain.ScoreContribution{
+			Description: describeFunction(function),
+			Value:       function.Value,
+		})
+	}
+
+	// The function scores sit below a "min of" applying max_boost, which is
+	// summed with the wrapped query score when boost_mode is sum
+	textScore := score - functions.Value
+	if n := len(ancestors); n >= 2 {
+		sum, minOf := ancestors[n-2], ancestors[n-1]
+		if len(sum.Details) > 1 && sum.Details[0] != minOf {
+			textScore = sum.Details[0].Value
+		}
+	}
+	return textScore, contributions
+}
+
+// findFunctions returns the node listing the function scores along with its
+// ancestors, outermost first
+func findFunctions(node *explanation, ancestors []*explanation) (*explanation, []*explanation) {
+	if strings.HasPrefix(node.Description, "function score, score mode") {
+		return node, ancestors
+	}
+	for _, detail := range node.Details {
+		if functions, path := findFunctions(detail, append(ancestors, node)); functions != nil {
+			return functions, path
+		}
+	}
+	return nil, nil
+}
+
+// describeFunction describes a single function score, skipping the match
+// filter of functions that apply to all products
+func describeFunction(function *explanation) string {
+	var parts []string
+	for _, detail := range function.Details {
+		if detail.Description == "match filter: *:*" {
+			continue
+		}
+		if strings.HasSuffix(detail.Description, ":") && len(detail.Details) > 0 {
+			for _, child := range detail.Details {
+				parts = append(parts, child.Description)
+			}
+			continue
+		}
+		parts = append(parts, detail.Description)
+	}
+	if len(parts) == 0 {
+		return function.Description
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,114 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"golang-ecommerce-search/internal/domain"
+)
+
+// productDocument is the indexed form of a product. It also holds the
+// embedding, and always carries the variants so that removing the last
+// variant clears them in partial updates.
+type productDocument struct {
+	*domain.Product
+	Variants  []domain.ProductVariant `json:"variants"`
+	Embedding []float32               `json:"embedding,omitempty"`
+}
+
+func newProductDocument(product *domain.Product) productDocument {
+	variants := product.Variants
+	if variants == nil {
+		variants = []domain.ProductVariant{}
+	}
+	return productDocument{Product: product, Variants: variants, Embedding: product.Embedding}
+}
+
+// EnsureMapping sets up the fields of the product index that dynamic mapping
+// cannot infer, creating the index if it does not exist yet: availability and
+// status are matched exactly, variants are nested so their attributes and
+// stock are matched together, and with positive dimensions the dense_vector
+// embedding field is added. Products indexed before a field existed get it
+// when they are next updated.
+func (r *productRepository) EnsureMapping(dimensions int) error {
+	ctx := context.Background()
+	mappings := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"availability": map[string]interface{}{"type": "keyword"},
+			"status":       map[string]interface{}{"type": "keyword"},
+			"variants": map[string]interface{}{
+				"type": "nested",
+				"properties": map[string]interface{}{
+					"sku":        map[string]interface{}{"type": "keyword"},
+					"price":      map[string]interface{}{"type": "double"},
+					"stock":      map[string]interface{}{"type": "long"},
+					"attributes": map[string]interface{}{"type": "object"},
+				},
+			},
+		},
+		// Variant attributes are matched exactly
+		"dynamic_templates": []map[string]interface{}{
+			{
+				"variant_attributes": map[string]interface{}{
+					"path_match":         "variants.attributes.*",
+					"match_mapping_type": "string",
+					"mapping":            map[string]interface{}{"type": "keyword"},
+				},
+			},
+		},
+	}
+	if dimensions > 0 {
+		mappings["properties"].(map[string]interface{})[embeddingField] = map[string]interface{}{
+			"type":       "dense_vector",
+			"dims":       dimensions,
+			"index":      true,
+			"similarity": "cosine",
+		}
+	}
+
+	exists, err := r.client.Indices.Exists([]string{r.index}, r.client.Indices.Exists.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	exists.Body.Close()
+
+	if exists.StatusCode == 404 {
+		body, err := json.Marshal(map[string]interface{}{"mappings": mappings})
+		if err != nil {
+			return err
+		}
+		res, err := r.client.Indices.Create(
+			r.index,
+			r.client.Indices.Create.WithContext(ctx),
+			r.client.Indices.Create.WithBody(bytes.NewReader(body)),
+		)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if res.IsError() {
+			return fmt.Errorf("failed to create index %s: %s", r.index, res.String())
+		}
+		return nil
+	}
+
+	body, err := json.Marshal(mappings)
+	if err != nil {
+		return err
+	}
+	res, err := r.client.Indices.PutMapping(
+		[]string{r.index},
+		bytes.NewReader(body),
+		r.client.Indices.PutMapping.WithContext(ctx),
+	)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("failed to update the mapping of index %s: %s", r.index, res.String())
+	}
+	return nil
+}
@@ -12,15 +12,27 @@ import (
 	"github.com/elastic/go-elasticsearch/v8"
 )
 
+// collapseInnerHits names the inner hits holding the top products of a
+// collapsed group, and collapseGroupCount the aggregation counting the groups
+const (
+	collapseInnerHits  = "group"
+	collapseGroupCount = "group_count"
+)
+
 type productRepository struct {
 	client *elasticsearch.Client
 	index  string
 }
 
 type ProductRepository interface {
-	Search(params domain.SearchParams) ([]*domain.Product, error)
+	Search(params domain.SearchParams) (*domain.SearchResult, error)
+	Explain(params domain.SearchParams) (*domain.SearchExplanation, error)
+	Similar(id string, params domain.SearchParams) ([]*domain.Product, error)
+	Terms(field string, size int) ([]string, error)
+	EnsureMapping(dimensions int) error
 	Create(product *domain.Product) error
 	Update(product *domain.Product) error
+	UpdateFields(id string, fields map[string]interface{}) error
 	Delete(id string) error
 	GetByID(id string) (*domain.Product, error)
 	IncrementViews(id string) error
@@ -36,7 +48,7 @@ func NewProductRepository(client *elasticsearch.Client, index string) ProductRep
 
 func (r *productRepository) Create(product *domain.Product) error {
 	ctx := context.Background()
-	body, err := json.Marshal(product)
+	body, err := json.Marshal(newProductDocument(product))
 	if err != nil {
 		return err
 	}
@@ -52,7 +64,7 @@ func (r *productRepository) Create(product *domain.Product) error {
 
 func (r *productRepository) Update(product *domain.Product) error {
 	ctx := context.Background()
-	body, err := json.Marshal(product)
+	body, err := json.Marshal(newProductDocument(product))
 	if err != nil {
 		return err
 	}
@@ -66,6 +78,23 @@ func (r *productRepository) Update(product *domain.Product) error {
 	return err
 }
 
+// UpdateFields partially updates an indexed product with fields by JSON name
+func (r *productRepository) UpdateFields(id string, fields map[string]interface{}) error {
+	ctx := context.Background()
+	body, err := json.Marshal(map[string]interface{}{"doc": fields})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.Update(
+		r.index,
+		id,
+		bytes.NewReader(body),
+		r.client.Update.WithContext(ctx),
+	)
+	return err
+}
+
 func (r *productRepository) Delete(id string) error {
 	ctx := context.Background()
 	_, err := r.client.Delete(
@@ -144,8 +173,22 @@ func (r *productRepository) IncrementBuys(id string) error {
 	return err
 }
 
-func (r *productRepository) Search(params domain.SearchParams) ([]*domain.Product, error) {
+func (r *productRepository) Search(params domain.SearchParams) (*domain.SearchResult, error) {
 	ctx := context.Background()
+	result, err := r.search(ctx, buildSearchBody(params))
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Page <= 1 && pinsApply(params) {
+		result.Products = placePins(result.Products, params.Rules.Pins)
+	}
+	return result, nil
+}
+
+// buildSearchBody builds the search request body: the text or advanced query
+// and filters, scored by the ranking profile and merchandising rules
+func buildSearchBody(params domain.SearchParams) map[string]interface{} {
 	query := strings.ToLower(params.Query)
 
 	// Build the query
@@ -155,58 +198,121 @@ func (r *productRepository) Search(params domain.SearchParams) ([]*domain.Produc
 		},
 	}
 
-	// Add text search if query is provided
-	if query != "" {
+	profile := domain.GetRankingProfile(params.RankingProfile)
+
+	// Add the advanced query expression, or the text search if a query is provided
+	if params.Expression != nil {
 		queryMap["bool"].(map[string]interface{})["must"] = append(
 			queryMap["bool"].(map[string]interface{})["must"].([]map[string]interface{}),
-			map[string]interface{}{
-				"multi_match": map[string]interface{}{
-					"query":  query,
-					"fields": []string{"name^3", "description^2", "category", "tags"},
-				},
-			},
+			compileExpression(params.Expression, profile),
 		)
-	}
-
-	// Add category filter if provided
-	if len(params.Categories) > 0 {
+	} else if query != "" {
 		queryMap["bool"].(map[string]interface{})["must"] = append(
 			queryMap["bool"].(map[string]interface{})["must"].([]map[string]interface{}),
 			map[string]interface{}{
-				"terms": map[string]interface{}{
-					"category.keyword": params.Categories,
-				},
+				"multi_match": textMatch(query, profile, params),
 			},
 		)
 	}
 
-	// Add brand filter if provided
-	if len(params.Brands) > 0 {
-		queryMap["bool"].(map[string]interface{})["must"] = append(
-			queryMap["bool"].(map[string]interface{})["must"].([]map[string]interface{}),
-			map[string]interface{}{
-				"terms": map[string]interface{}{
-					"brand.keyword": params.Brands,
+	// Add category, brand and price filters
+	queryMap["bool"].(map[string]interface{})["must"] = append(
+		queryMap["bool"].(map[string]interface{})["must"].([]map[string]interface{}),
+		buildFilters(params)...,
+	)
+
+	// Build the sort
+	sort := buildSort(params.Sort)
+
+	// Calculate pagination
+	from := (params.Page - 1) * params.PageSize
+	if from < 0 {
+		from = 0
+	}
+
+	scoreQuery := map[string]interface{}{
+		"function_score": map[string]interface{}{
+			"query":      queryMap,
+			"functions":  append(popularityFunctions(profile), boostFunctions(params.Rules)...),
+			"score_mode": "sum",
+			"boost_mode": "sum",
+		},
+	}
+
+	scoreQuery = demoteOutOfStock(scoreQuery)
+
+	body := map[string]interface{}{
+		"sort":    sort,
+		"from":    from,
+		"size":    params.PageSize,
+		"_source": map[string]interface{}{"excludes": []string{embeddingField}},
+	}
+
+	// Vector searches rank by the query embedding alone, hybrid searches add
+	// the vector similarity to the keyword score
+	switch {
+	case usesVector(params) && params.Mode == domain.SearchModeVector:
+		body["knn"] = knnQuery(params, from+params.PageSize, 1)
+	case usesVector(params):
+		body["query"] = applyRules(scoreQuery, params)
+		body["knn"] = knnQuery(params, from+params.PageSize, hybridVectorBoost)
+	default:
+		body["query"] = applyRules(scoreQuery, params)
+	}
+
+	// Collapse the results per brand or category, keeping the top hits of each group
+	if params.Collapse != nil {
+		collapse := map[string]interface{}{
+			"field": params.Collapse.Field + ".keyword",
+		}
+		if params.Collapse.Size > 1 {
+			collapse["inner_hits"] = map[string]interface{}{
+				"name": collapseInnerHits,
+				"size": params.Collapse.Size,
+				"sort": sort,
+			}
+		}
+		body["collapse"] = collapse
+		// Pages hold groups, so the total counts groups rather than hits
+		body["aggs"] = map[string]interface{}{
+			collapseGroupCount: map[string]interface{}{
+				"cardinality": map[string]interface{}{"field": params.Collapse.Field + ".keyword"},
+			},
+		}
+	}
+
+	return body
+}
+
+// Similar finds products related to the given product using more_like_this,
+// restricted by the same filters as Search and ranked with popularity.
+func (r *productRepository) Similar(id string, params domain.SearchParams) ([]*domain.Product, error) {
+	ctx := context.Background()
+
+	must := []map[string]interface{}{
+		{
+			"more_like_this": map[string]interface{}{
+				"fields": []string{"name", "description", "tags", "category", "brand"},
+				"like": []map[string]interface{}{
+					{"_index": r.index, "_id": id},
 				},
+				"min_term_freq":   1,
+				"min_doc_freq":    1,
+				"max_query_terms": 25,
 			},
-		)
+		},
 	}
+	must = append(must, buildFilters(params)...)
 
-	// Build the sort
-	var sort []map[string]interface{}
-	switch params.SortBy {
-	case "views":
-		sort = append(sort, map[string]interface{}{"views": "desc"})
-	case "buys":
-		sort = append(sort, map[string]interface{}{"buys": "desc"})
-	default:
-		// Default sort by score (relevance) and then by views and buys
-		sort = append(sort, map[string]interface{}{"_score": "desc"})
-		sort = append(sort, map[string]interface{}{"views": "desc"})
-		sort = append(sort, map[string]interface{}{"buys": "desc"})
+	queryMap := map[string]interface{}{
+		"bool": map[string]interface{}{
+			"must": must,
+			"must_not": []map[string]interface{}{
+				{"ids": map[string]interface{}{"values": []string{id}}},
+			},
+		},
 	}
 
-	// Calculate pagination
 	from := (params.Page - 1) * params.PageSize
 	if from < 0 {
 		from = 0
@@ -215,32 +321,80 @@ func (r *productRepository) Search(params domain.SearchParams) ([]*domain.Produc
 	body := map[string]interface{}{
 		"query": map[string]interface{}{
 			"function_score": map[string]interface{}{
-				"query": queryMap,
-				"functions": []map[string]interface{}{
-					{
-						"field_value_factor": map[string]interface{}{
-							"field":    "buys",
-							"factor":   0.3,
-							"modifier": "log1p",
-						},
-					},
-					{
-						"field_value_factor": map[string]interface{}{
-							"field":    "views",
-							"factor":   0.1,
-							"modifier": "log1p",
-						},
-					},
-				},
+				"query":      queryMap,
+				"functions":  popularityFunctions(domain.GetRankingProfile(params.RankingProfile)),
 				"score_mode": "sum",
 				"boost_mode": "sum",
 			},
 		},
-		"sort": sort,
 		"from": from,
 		"size": params.PageSize,
 	}
 
+	result, err := r.search(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	return result.Products, nil
+}
+
+// Terms returns the most frequent values of a keyword field, such as the
+// brand and category dictionaries used for query understanding
+func (r *productRepository) Terms(field string, size int) ([]string, error) {
+	ctx := context.Background()
+	body := map[string]interface{}{
+		"size": 0,
+		"aggs": map[string]interface{}{
+			"terms": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": field,
+					"size":  size,
+				},
+			},
+		},
+	}
+
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.client.Search(
+		r.client.Search.WithContext(ctx),
+		r.client.Search.WithIndex(r.index),
+		r.client.Search.WithBody(bytes.NewReader(bodyBytes)),
+	)
+	if err != nil {
+		return nil, transportError(err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, responseError("search request", res)
+	}
+
+	var result struct {
+		Aggregations struct {
+			Terms struct {
+				Buckets []struct {
+					Key string `json:"key"`
+				} `json:"buckets"`
+			} `json:"terms"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	terms := make([]string, len(result.Aggregations.Terms.Buckets))
+	for i, bucket := range result.Aggregations.Terms.Buckets {
+		terms[i] = bucket.Key
+	}
+	return terms, nil
+}
+
+// search executes a search request body and decodes the hits into products
+func (r *productRepository) search(ctx context.Context, body map[string]interface{}) (*domain.SearchResult, error) {
 	bodyBytes, err := json.Marshal(body)
 	if err != nil {
 		return nil, err
@@ -255,25 +409,255 @@ func (r *productRepository) Search(params domain.SearchParams) ([]*domain.Produc
 		r.client.Search.WithBody(bytes.NewReader(bodyBytes)),
 	)
 	if err != nil {
-		return nil, err
+		return nil, transportError(err)
 	}
 	defer res.Body.Close()
 
+	if res.IsError() {
+		return nil, responseError("search request", res)
+	}
+
 	var result struct {
 		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
 			Hits []struct {
-				Source domain.Product `json:"_source"`
+				Source    domain.Product           `json:"_source"`
+				Fields    map[string][]interface{} `json:"fields"`
+				InnerHits map[string]struct {
+					Hits struct {
+						Hits []struct {
+							Source domain.Product `json:"_source"`
+						} `json:"hits"`
+					} `json:"hits"`
+				} `json:"inner_hits"`
 			} `json:"hits"`
 		} `json:"hits"`
+		Aggregations map[string]struct {
+			Value int64 `json:"value"`
+		} `json:"aggregations"`
 	}
 	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
 		return nil, err
 	}
 
-	products := make([]*domain.Product, len(result.Hits.Hits))
-	for i, hit := range result.Hits.Hits {
-		products[i] = &hit.Source
+	searchResult := &domain.SearchResult{
+		Products: make([]*domain.Product, 0, len(result.Hits.Hits)),
+		Total:    result.Hits.Total.Value,
+	}
+	for _, hit := range result.Hits.Hits {
+		source := hit.Source
+		searchResult.Products = append(searchResult.Products, &source)
+
+		// Collapsed hits carry the top products of their group as inner hits
+		if group, ok := hit.InnerHits[collapseInnerHits]; ok && len(group.Hits.Hits) > 0 {
+			products := make([]*domain.Product, 0, len(group.Hits.Hits))
+			for _, inner := range group.Hits.Hits {
+				source := inner.Source
+				products = append(products, &source)
+			}
+			searchResult.Groups = append(searchResult.Groups, &domain.ProductGroup{Value: collapseValue(hit.Fields), Products: products})
+		}
+	}
+	if groups, ok := result.Aggregations[collapseGroupCount]; ok {
+		searchResult.Total = groups.Value
+	}
+	return searchResult, nil
+}
+
+// collapseValue returns the value a hit was collapsed on
+func collapseValue(fields map[string][]interface{}) string {
+	for _, values := range fields {
+		if len(values) > 0 {
+			if value, ok := values[0].(string); ok {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// sortFields maps domain sort fields to their Elasticsearch field names
+var sortFields = map[string]string{
+	domain.SortFieldRelevance: "_score",
+	domain.SortFieldPrice:     "price",
+	domain.SortFieldCreatedAt: "created_at",
+	domain.SortFieldName:      "name.keyword",
+	domain.SortFieldViews:     "views",
+	domain.SortFieldBuys:      "buys",
+}
+
+func buildSort(fields []domain.SortField) []map[string]interface{} {
+	var sort []map[string]interface{}
+	for _, f := range fields {
+		if esField, ok := sortFields[f.Field]; ok {
+			sort = append(sort, map[string]interface{}{esField: string(f.Order)})
+		}
+	}
+
+	if len(sort) == 0 {
+		// Default sort by score (relevance) and then by views and buys
+		sort = append(sort, map[string]interface{}{"_score": "desc"})
+		sort = append(sort, map[string]interface{}{"views": "desc"})
+		sort = append(sort, map[string]interface{}{"buys": "desc"})
 	}
+	return sort
+}
 
-	return products, nil
+// textMatch builds the multi_match of the free text query, applying the
+// operator and fuzziness overrides used by zero-result recovery
+func textMatch(query string, profile domain.RankingProfile, params domain.SearchParams) map[string]interface{} {
+	match := map[string]interface{}{
+		"query":    query,
+		"fields":   profile.Fields,
+		"type":     profile.MatchType,
+		"operator": profile.Operator,
+	}
+	if params.Operator != "" {
+		match["operator"] = params.Operator
+	}
+	if params.Fuzzy {
+		// Fuzziness is not supported by cross_fields matching
+		match["type"] = "best_fields"
+		match["fuzziness"] = "AUTO"
+	}
+	return match
+}
+
+// buildFilters builds the status, category, brand and price clauses shared by the search queries
+func buildFilters(params domain.SearchParams) []map[string]interface{} {
+	// Only published products are searchable
+	filters := []map[string]interface{}{
+		{
+			"bool": map[string]interface{}{
+				"must_not": map[string]interface{}{
+					"terms": map[string]interface{}{"status": domain.HiddenStatuses},
+				},
+			},
+		},
+	}
+
+	// Add category filter if provided
+	if len(params.Categories) > 0 {
+		filters = append(filters, map[string]interface{}{
+			"terms": map[string]interface{}{
+				"category.keyword": params.Categories,
+			},
+		})
+	}
+
+	// Add brand filter if provided
+	if len(params.Brands) > 0 {
+		filters = append(filters, map[string]interface{}{
+			"terms": map[string]interface{}{
+				"brand.keyword": params.Brands,
+			},
+		})
+	}
+
+	// Add price range filter if provided
+	if params.MinPrice != nil || params.MaxPrice != nil {
+		priceRange := map[string]interface{}{}
+		if params.MinPrice != nil {
+			priceRange["gte"] = *params.MinPrice
+		}
+		if params.MaxPrice != nil {
+			priceRange["lte"] = *params.MaxPrice
+		}
+		filters = append(filters, map[string]interface{}{
+			"range": map[string]interface{}{
+				"price": priceRange,
+			},
+		})
+	}
+
+	// Add variant attribute and stock filters if provided
+	if variants := variantFilter(params); variants != nil {
+		filters = append(filters, variants)
+	}
+	// Products indexed before availability existed have none and stay visible
+	if params.InStock {
+		filters = append(filters, map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must_not": map[string]interface{}{
+					"term": map[string]interface{}{"availability": domain.AvailabilityOutOfStock},
+				},
+			},
+		})
+	}
+
+	return filters
+}
+
+// variantFilter matches products with a variant having all the attributes,
+// and in stock when required. Variants are nested, so the conditions must
+// hold for the same variant.
+func variantFilter(params domain.SearchParams) map[string]interface{} {
+	if len(params.VariantAttributes) == 0 {
+		return nil
+	}
+
+	var conditions []map[string]interface{}
+	for name, value := range params.VariantAttributes {
+		conditions = append(conditions, map[string]interface{}{
+			"term": map[string]interface{}{"variants.attributes." + name: value},
+		})
+	}
+	if params.InStock {
+		conditions = append(conditions, map[string]interface{}{
+			"range": map[string]interface{}{"variants.stock": map[string]interface{}{"gt": 0}},
+		})
+	}
+
+	return map[string]interface{}{
+		"nested": map[string]interface{}{
+			"path": "variants",
+			"query": map[string]interface{}{
+				"bool": map[string]interface{}{"filter": conditions},
+			},
+		},
+	}
+}
+
+// outOfStockFactor multiplies the score of out of stock products so they rank
+// below the available products matching the query equally well
+const outOfStockFactor = 0.1
+
+// demoteOutOfStock lowers the score of out of stock products with a boosting
+// query, which unlike a negative boost cannot make scores negative
+func demoteOutOfStock(query map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"boosting": map[string]interface{}{
+			"positive": query,
+			"negative": map[string]interface{}{
+				"term": map[string]interface{}{"availability": domain.AvailabilityOutOfStock},
+			},
+			"negative_boost": outOfStockFactor,
+		},
+	}
+}
+
+// popularityFunctions boosts products by their buys and views, weighted by the ranking profile
+func popularityFunctions(profile domain.RankingProfile) []map[string]interface{} {
+	functions := []map[string]interface{}{}
+	if profile.BuysFactor > 0 {
+		functions = append(functions, map[string]interface{}{
+			"field_value_factor": map[string]interface{}{
+				"field":    "buys",
+				"factor":   profile.BuysFactor,
+				"modifier": "log1p",
+			},
+		})
+	}
+	if profile.ViewsFactor > 0 {
+		functions = append(functions, map[string]interface{}{
+			"field_value_factor": map[string]interface{}{
+				"field":    "views",
+				"factor":   profile.ViewsFactor,
+				"modifier": "log1p",
+			},
+		})
+	}
+	return functions
 }
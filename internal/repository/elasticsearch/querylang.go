@@ -0,0 +1,88 @@
+package elasticsearch
+
+import (
+	"golang-ecommerce-search/internal/domain"
+	"golang-ecommerce-search/internal/querylang"
+)
+
+// compileExpression compiles an advanced query AST into an Elasticsearch
+// query. Free text terms search the fields of the ranking profile.
+func compileExpression(node querylang.Node, profile domain.RankingProfile) map[string]interface{} {
+	switch n := node.(type) {
+	case *querylang.And:
+		return map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": compileChildren(n.Children, profile),
+			},
+		}
+	case *querylang.Or:
+		return map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should":               compileChildren(n.Children, profile),
+				"minimum_should_match": 1,
+			},
+		}
+	case *querylang.Not:
+		return map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must_not": []map[string]interface{}{compileExpression(n.Child, profile)},
+			},
+		}
+	case *querylang.Term:
+		matchType := profile.MatchType
+		if n.Phrase {
+			matchType = "phrase"
+		}
+		return map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":    n.Text,
+				"fields":   profile.Fields,
+				"type":     matchType,
+				"operator": "and",
+			},
+		}
+	case *querylang.Match:
+		if n.Phrase {
+			return map[string]interface{}{
+				"match_phrase": map[string]interface{}{n.Field: n.Text},
+			}
+		}
+		return map[string]interface{}{
+			"match": map[string]interface{}{
+				n.Field: map[string]interface{}{"query": n.Text, "operator": "and"},
+			},
+		}
+	case *querylang.Compare:
+		if n.Op == querylang.OpEq {
+			return map[string]interface{}{
+				"term": map[string]interface{}{n.Field: n.Value},
+			}
+		}
+		ops := map[querylang.Operator]string{
+			querylang.OpLt:  "lt",
+			querylang.OpLte: "lte",
+			querylang.OpGt:  "gt",
+			querylang.OpGte: "gte",
+		}
+		return map[string]interface{}{
+			"range": map[string]interface{}{
+				n.Field: map[string]interface{}{ops[n.Op]: n.Value},
+			},
+		}
+	case *querylang.Range:
+		return map[string]interface{}{
+			"range": map[string]interface{}{
+				n.Field: map[string]interface{}{"gte": n.From, "lte": n.To},
+			},
+		}
+	}
+	return map[string]interface{}{"match_none": map[string]interface{}{}}
+}
+
+func compileChildren(children []querylang.Node, profile domain.RankingProfile) []map[string]interface{} {
+	clauses := make([]map[string]interface{}, len(children))
+	for i, child := range children {
+		clauses[i] = compileExpression(child, profile)
+	}
+	return clauses
+}
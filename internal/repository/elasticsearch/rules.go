@@ -0,0 +1,139 @@
+package elasticsearch
+
+import (
+	"golang-ecommerce-search/internal/domain"
+)
+
+// applyRules wraps the scored search query with the merchandising rule effects:
+// buried products are demoted with a boosting query, pinned products are
+// promoted with a pinned query, and excluded products and rule category
+// filters are applied outside of both so they also hold for pinned products.
+func applyRules(query map[string]interface{}, params domain.SearchParams) map[string]interface{} {
+	effects := params.Rules
+	if effects.Empty() {
+		return query
+	}
+
+	if len(effects.Buries) > 0 {
+		query = map[string]interface{}{
+			"boosting": map[string]interface{}{
+				"positive": query,
+				"negative": map[string]interface{}{
+					"ids": map[string]interface{}{"values": effects.Buries},
+				},
+				"negative_boost": effects.BuryFactor,
+			},
+		}
+	}
+
+	pinned := pinsApply(params)
+	if pinned {
+		ids := make([]string, len(effects.Pins))
+		for i, pin := range effects.Pins {
+			ids[i] = pin.ProductID
+		}
+		query = map[string]interface{}{
+			"pinned": map[string]interface{}{
+				"ids":     ids,
+				"organic": query,
+			},
+		}
+	}
+
+	var filters []map[string]interface{}
+	if pinned {
+		// Pinned products bypass the organic query, so filter them again
+		filters = append(filters, buildFilters(params)...)
+	}
+	if len(effects.Categories) > 0 {
+		filters = append(filters, map[string]interface{}{
+			"terms": map[string]interface{}{
+				"category.keyword": effects.Categories,
+			},
+		})
+	}
+
+	if len(filters) == 0 && len(effects.Excludes) == 0 {
+		return query
+	}
+
+	boolQuery := map[string]interface{}{
+		"must": []map[string]interface{}{query},
+	}
+	if len(filters) > 0 {
+		boolQuery["filter"] = filters
+	}
+	if len(effects.Excludes) > 0 {
+		boolQuery["must_not"] = []map[string]interface{}{
+			{"ids": map[string]interface{}{"values": effects.Excludes}},
+		}
+	}
+	return map[string]interface{}{"bool": boolQuery}
+}
+
+// boostFunctions adds the rule boost weights to the score of the boosted products
+func boostFunctions(effects *domain.RuleEffects) []map[string]interface{} {
+	if effects.Empty() {
+		return nil
+	}
+
+	// Group products by weight to keep the number of functions small
+	byWeight := make(map[float64][]string)
+	var weights []float64
+	for id, weight := range effects.Boosts {
+		if _, ok := byWeight[weight]; !ok {
+			weights = append(weights, weight)
+		}
+		byWeight[weight] = append(byWeight[weight], id)
+	}
+
+	var functions []map[string]interface{}
+	for _, weight := range weights {
+		functions = append(functions, map[string]interface{}{
+			"filter": map[string]interface{}{
+				"ids": map[string]interface{}{"values": byWeight[weight]},
+			},
+			"weight": weight,
+		})
+	}
+	return functions
+}
+
+// pinsApply reports whether products are pinned for the search. Pins only make
+// sense when results are ordered by relevance.
+func pinsApply(params domain.SearchParams) bool {
+	if params.Rules.Empty() || len(params.Rules.Pins) == 0 {
+		return false
+	}
+	return len(params.Sort) == 0 || params.Sort[0].Field == domain.SortFieldRelevance
+}
+
+// placePins moves the pinned products, which the pinned query returns first,
+// to their positions on the first page. Positions beyond the page are clamped
+// to its end, so the page keeps the same products and paging stays consistent.
+func placePins(products []*domain.Product, pins []domain.Pin) []*domain.Product {
+	positions := make(map[string]int, len(pins))
+	for _, pin := range pins {
+		positions[pin.ProductID] = pin.Position
+	}
+
+	var pinned, organic []*domain.Product
+	for _, product := range products {
+		if _, ok := positions[product.ID]; ok {
+			pinned = append(pinned, product)
+		} else {
+			organic = append(organic, product)
+		}
+	}
+
+	result := make([]*domain.Product, 0, len(products))
+	for _, product := range pinned {
+		index := positions[product.ID] - 1
+		for len(result) < index && len(organic) > 0 {
+			result = append(result, organic[0])
+			organic = organic[1:]
+		}
+		result = append(result, product)
+	}
+	return append(result, organic...)
+}
@@ -0,0 +1,72 @@
+package elasticsearch
+
+import (
+	"reflect"
+	"testing"
+
+	"golang-ecommerce-search/internal/domain"
+)
+
+func TestPlacePins(t *testing.T) {
+	tests := []struct {
+		name     string
+		products []string
+		pins     []domain.Pin
+		want     []string
+	}{
+		{
+			name:     "no pins",
+			products: []string{"o1", "o2"},
+			pins:     nil,
+			want:     []string{"o1", "o2"},
+		},
+		{
+			name:     "pins are moved to their positions",
+			products: []string{"a", "b", "o1", "o2", "o3"},
+			pins:     []domain.Pin{{ProductID: "a", Position: 1}, {ProductID: "b", Position: 3}},
+			want:     []string{"a", "o1", "b", "o2", "o3"},
+		},
+		{
+			name:     "pins beyond the result length are clamped to the end",
+			products: []string{"a", "o1", "o2"},
+			pins:     []domain.Pin{{ProductID: "a", Position: 10}},
+			want:     []string{"o1", "o2", "a"},
+		},
+		{
+			name:     "pins beyond the result length keep their order",
+			products: []string{"a", "b", "o1"},
+			pins:     []domain.Pin{{ProductID: "a", Position: 1}, {ProductID: "b", Position: 9}},
+			want:     []string{"a", "o1", "b"},
+		},
+		{
+			name:     "duplicate pins place the product once",
+			products: []string{"a", "o1", "o2", "o3"},
+			pins:     []domain.Pin{{ProductID: "a", Position: 1}, {ProductID: "a", Position: 3}},
+			want:     []string{"o1", "o2", "a", "o3"},
+		},
+		{
+			name:     "pins on excluded products missing from the results are skipped",
+			products: []string{"b", "o1", "o2"},
+			pins:     []domain.Pin{{ProductID: "x", Position: 1}, {ProductID: "b", Position: 2}},
+			want:     []string{"o1", "b", "o2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products := make([]*domain.Product, len(tt.products))
+			for i, id := range tt.products {
+				products[i] = &domain.Product{ID: id}
+			}
+
+			result := placePins(products, tt.pins)
+			got := make([]string, len(result))
+			for i, product := range result {
+				got[i] = product.ID
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("placePins() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
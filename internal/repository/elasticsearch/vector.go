@@ -0,0 +1,72 @@
+package elasticsearch
+
+import (
+	"golang-ecommerce-search/internal/domain"
+)
+
+// embeddingField is the dense_vector field holding the product embeddings
+const embeddingField = "embedding"
+
+const (
+	// hybridVectorBoost weighs the vector similarity, which is between 0 and
+	// 1 for cosine similarity, against the unbounded BM25 and popularity score
+	hybridVectorBoost = 10.0
+	// numCandidatesFactor is how many candidates per result each shard
+	// considers during the approximate nearest neighbour search
+	numCandidatesFactor = 10
+	// maxKnnCandidates is the Elasticsearch limit on num_candidates
+	maxKnnCandidates = 10000
+)
+
+// usesVector reports whether the search matches by the query embedding
+func usesVector(params domain.SearchParams) bool {
+	return len(params.Vector) > 0 && (params.Mode == domain.SearchModeVector || params.Mode == domain.SearchModeHybrid)
+}
+
+// knnQuery finds the k products nearest to the query embedding. The search
+// filters, rule category filters and exclusions are applied during the
+// nearest neighbour search so that k products pass them.
+func knnQuery(params domain.SearchParams, k int, boost float64) map[string]interface{} {
+	numCandidates := k * numCandidatesFactor
+	if numCandidates < 100 {
+		numCandidates = 100
+	}
+	if numCandidates > maxKnnCandidates {
+		numCandidates = maxKnnCandidates
+	}
+	if k > numCandidates {
+		k = numCandidates
+	}
+
+	knn := map[string]interface{}{
+		"field":          embeddingField,
+		"query_vector":   params.Vector,
+		"k":              k,
+		"num_candidates": numCandidates,
+		"boost":          boost,
+	}
+
+	filters := buildFilters(params)
+	effects := params.Rules
+	if !effects.Empty() && len(effects.Categories) > 0 {
+		filters = append(filters, map[string]interface{}{
+			"terms": map[string]interface{}{
+				"category.keyword": effects.Categories,
+			},
+		})
+	}
+
+	filter := map[string]interface{}{}
+	if len(filters) > 0 {
+		filter["filter"] = filters
+	}
+	if !effects.Empty() && len(effects.Excludes) > 0 {
+		filter["must_not"] = []map[string]interface{}{
+			{"ids": map[string]interface{}{"values": effects.Excludes}},
+		}
+	}
+	if len(filter) > 0 {
+		knn["filter"] = map[string]interface{}{"bool": filter}
+	}
+	return knn
+}
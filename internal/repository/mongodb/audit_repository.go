@@ -0,0 +1,127 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"golang-ecommerce-search/internal/domain"
+	"golang-ecommerce-search/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AuditRepository interface {
+	Create(entry *domain.AuditEntry) error
+	ListByProduct(productID string, limit int) ([]*domain.AuditEntry, error)
+	GetRevision(productID string, revision int64) (*domain.AuditEntry, error)
+}
+
+type auditRepository struct {
+	collection *mongo.Collection
+	// counters holds the last revision allocated per product
+	counters *mongo.Collection
+}
+
+func NewAuditRepository(db *mongo.Database, collectionName string) AuditRepository {
+	collection := db.Collection(collectionName)
+	return &auditRepository{
+		collection: collection,
+		counters:   db.Collection(collectionName + "_revisions"),
+	}
+}
+
+// Create stores an audit entry as the next revision of its product
+func (r *auditRepository) Create(entry *domain.AuditEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	revision, err := r.nextRevision(ctx, entry.ProductID)
+	if err != nil {
+		return err
+	}
+
+	entry.ID = model.NewID().String()
+	entry.Revision = revision
+	entry.CreatedAt = time.Now()
+
+	_, err = r.collection.InsertOne(ctx, entry)
+	return translateError(err)
+}
+
+// nextRevision atomically increments the revision counter of a product so
+// that concurrent entries never share a revision. A missing counter is
+// created from the last stored revision; if another writer creates it first
+// the increment is retried.
+func (r *auditRepository) nextRevision(ctx context.Context, productID string) (int64, error) {
+	var counter struct {
+		Revision int64 `bson:"revision"`
+	}
+	increment := bson.M{"$inc": bson.M{"revision": 1}}
+	after := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	err := r.counters.FindOneAndUpdate(ctx, bson.M{"_id": productID}, increment, after).Decode(&counter)
+	if err == nil {
+		return counter.Revision, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, translateError(err)
+	}
+
+	var last domain.AuditEntry
+	opts := options.FindOne().SetSort(bson.D{{Key: "revision", Value: -1}}).SetProjection(bson.M{"revision": 1})
+	err = r.collection.FindOne(ctx, bson.M{"product_id": productID}, opts).Decode(&last)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, translateError(err)
+	}
+
+	revision := last.Revision + 1
+	_, err = r.counters.InsertOne(ctx, bson.M{"_id": productID, "revision": revision})
+	if mongo.IsDuplicateKeyError(err) {
+		err = r.counters.FindOneAndUpdate(ctx, bson.M{"_id": productID}, increment, after).Decode(&counter)
+		if err != nil {
+			return 0, translateError(err)
+		}
+		return counter.Revision, nil
+	}
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return revision, nil
+}
+
+// ListByProduct returns the most recent audit entries of a product, newest first
+func (r *auditRepository) ListByProduct(productID string, limit int) ([]*domain.AuditEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "revision", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, bson.M{"product_id": productID}, opts)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer cursor.Close(ctx)
+
+	entries := []*domain.AuditEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, translateError(err)
+	}
+	return entries, nil
+}
+
+func (r *auditRepository) GetRevision(productID string, revision int64) (*domain.AuditEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var entry domain.AuditEntry
+	err := r.collection.FindOne(ctx, bson.M{"product_id": productID, "revision": revision}).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &entry, nil
+}
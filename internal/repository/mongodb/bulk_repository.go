@@ -0,0 +1,125 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang-ecommerce-search/internal/domain"
+	"golang-ecommerce-search/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Kinds of product writes
+const (
+	WriteCreate = "create"
+	WriteUpdate = "update"
+	WriteDelete = "delete"
+)
+
+// duplicateKeyCode is the server error code of a duplicate key
+const duplicateKeyCode = 11000
+
+// ProductWrite is a write of a bulk request: a product to create, the
+// editable fields of a product to update, or the ID of a product to delete
+type ProductWrite struct {
+	Kind    string
+	ID      string
+	Product *domain.Product
+}
+
+// BulkWrite creates the products in a single unordered bulk request, so a
+// failing insert does not stop the others, and applies every update and
+// delete on its own so that one matching no product is reported. Created
+// products keep their ID when they have one, and updates with a version only
+// apply if the stored product is still at that version. It returns the errors of the
+// failed writes by index, or an error when the request as a whole failed.
+func (r *productRepository) BulkWrite(writes []ProductWrite) (map[int]error, error) {
+	failed := make(map[int]error)
+	if len(writes) == 0 {
+		return failed, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var models []mongo.WriteModel
+	var created []int
+	for i, write := range writes {
+		switch write.Kind {
+		case WriteCreate:
+			product := write.Product
+			if product.ID == "" {
+				product.ID = model.NewID().String()
+			}
+			product.CreatedAt = now
+			product.UpdatedAt = now
+			product.Version = 1
+			models = append(models, mongo.NewInsertOneModel().SetDocument(product))
+			created = append(created, i)
+		case WriteUpdate, WriteDelete:
+		default:
+			return nil, fmt.Errorf("unknown product write %q", write.Kind)
+		}
+	}
+
+	if len(models) > 0 {
+		_, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		var bulkErr mongo.BulkWriteException
+		if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil {
+			for _, writeErr := range bulkErr.WriteErrors {
+				failed[created[writeErr.Index]] = bulkWriteError(writeErr)
+			}
+		} else if err != nil {
+			return nil, translateError(err)
+		}
+	}
+
+	for i, write := range writes {
+		switch write.Kind {
+		case WriteUpdate:
+			write.Product.UpdatedAt = now
+			filter := activeProduct(write.ID)
+			if write.Product.Version > 0 {
+				filter["version"] = write.Product.Version
+			}
+			if err := r.updateOne(ctx, write.ID, filter, editableUpdate(write.Product)); err != nil {
+				failed[i] = err
+			}
+		case WriteDelete:
+			update := bson.M{"$set": bson.M{"deleted_at": now, "updated_at": now}}
+			if err := r.updateOne(ctx, write.ID, activeProduct(write.ID), update); err != nil {
+				failed[i] = err
+			}
+		}
+	}
+	return failed, nil
+}
+
+// updateOne applies an update or delete of a bulk request. One that matched
+// no product is reported like a single update: the product was deleted after
+// the batch was checked, or changed since the expected version.
+func (r *productRepository) updateOne(ctx context.Context, id string, filter, update bson.M) error {
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return translateError(err)
+	}
+	if result.MatchedCount == 0 {
+		return r.updateMismatch(id)
+	}
+	return nil
+}
+
+// bulkWriteError describes the failure of a single write. A duplicate key
+// means a product with the ID exists, possibly soft deleted.
+func bulkWriteError(writeErr mongo.BulkWriteError) error {
+	if writeErr.HasErrorCode(duplicateKeyCode) {
+		return domain.NewError(domain.ErrorConflict, "a product with this id already exists or is deleted")
+	}
+	return writeErr.WriteError
+}
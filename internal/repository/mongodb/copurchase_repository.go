@@ -0,0 +1,98 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"golang-ecommerce-search/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CoPurchaseRepository interface {
+	IncrementPairs(productIDs []string) error
+	TopCoPurchased(productID string, limit int) ([]*domain.CoPurchase, error)
+	EnsureIndexes() error
+}
+
+type coPurchaseRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCoPurchaseRepository(db *mongo.Database, collectionName string) CoPurchaseRepository {
+	collection := db.Collection(collectionName)
+	return &coPurchaseRepository{
+		collection: collection,
+	}
+}
+
+// EnsureIndexes creates the index TopCoPurchased reads the most bought
+// together products of a product from, unless it exists
+func (r *coPurchaseRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "count", Value: -1}},
+	})
+	return translateError(err)
+}
+
+// IncrementPairs increments the co-purchase count of every ordered pair of
+// distinct products in the list. Each pair is stored in both directions so the
+// top products for a given product can be read with a single indexed query.
+func (r *coPurchaseRepository) IncrementPairs(productIDs []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var models []mongo.WriteModel
+	for _, productID := range productIDs {
+		for _, otherID := range productIDs {
+			if productID == otherID {
+				continue
+			}
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": productID + ":" + otherID}).
+				SetUpdate(bson.M{
+					"$inc": bson.M{"count": 1},
+					"$set": bson.M{
+						"product_id": productID,
+						"other_id":   otherID,
+						"updated_at": now,
+					},
+				}).
+				SetUpsert(true))
+		}
+	}
+
+	if len(models) == 0 {
+		return nil
+	}
+
+	_, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return translateError(err)
+}
+
+func (r *coPurchaseRepository) TopCoPurchased(productID string, limit int) ([]*domain.CoPurchase, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"product_id": productID}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "count", Value: -1}}).
+		SetLimit(int64(limit)))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var pairs []*domain.CoPurchase
+	if err := cursor.All(ctx, &pairs); err != nil {
+		return nil, translateError(err)
+	}
+
+	return pairs, nil
+}
@@ -0,0 +1,54 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"golang-ecommerce-search/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// activeProduct matches a product by ID unless it was soft deleted
+func activeProduct(id string) bson.M {
+	return bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}}
+}
+
+// Restore undoes the soft delete of a product and returns it
+func (r *productRepository) Restore(id string) (*domain.Product, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "deleted_at": bson.M{"$exists": true}}
+	update := bson.M{
+		"$unset": bson.M{"deleted_at": ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var product domain.Product
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&product)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrProductNotFound
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &product, nil
+}
+
+// PurgeDeleted permanently removes the products soft deleted before the given
+// time and returns how many were removed
+func (r *productRepository) PurgeDeleted(before time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"deleted_at": bson.M{"$lte": before}})
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,23 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+
+	"golang-ecommerce-search/internal/domain"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// translateError turns driver errors that callers cannot act on into domain
+// errors: timeouts and network failures mean the database is unavailable.
+// Other errors are returned as they are.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if mongo.IsTimeout(err) || mongo.IsNetworkError(err) || errors.Is(err, context.DeadlineExceeded) {
+		return domain.Unavailable("the database is unavailable", err)
+	}
+	return err
+}
@@ -0,0 +1,127 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"golang-ecommerce-search/internal/domain"
+	"golang-ecommerce-search/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ExperimentRepository interface {
+	Create(experiment *domain.Experiment) error
+	Update(experiment *domain.Experiment) error
+	Delete(id string) error
+	GetByID(id string) (*domain.Experiment, error)
+	List(status domain.ExperimentStatus) ([]*domain.Experiment, error)
+}
+
+type experimentRepository struct {
+	collection *mongo.Collection
+}
+
+func NewExperimentRepository(db *mongo.Database, collectionName string) ExperimentRepository {
+	collection := db.Collection(collectionName)
+	return &experimentRepository{
+		collection: collection,
+	}
+}
+
+func (r *experimentRepository) Create(experiment *domain.Experiment) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	experiment.ID = model.NewID().String()
+	experiment.CreatedAt = time.Now()
+	experiment.UpdatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, experiment)
+	return translateError(err)
+}
+
+func (r *experimentRepository) Update(experiment *domain.Experiment) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	experiment.UpdatedAt = time.Now()
+
+	filter := bson.M{"_id": experiment.ID}
+	update := bson.M{
+		"$set": bson.M{
+			"name":        experiment.Name,
+			"description": experiment.Description,
+			"status":      experiment.Status,
+			"variants":    experiment.Variants,
+			"updated_at":  experiment.UpdatedAt,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return translateError(err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrExperimentNotFound
+	}
+	return nil
+}
+
+func (r *experimentRepository) Delete(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id}
+	result, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return translateError(err)
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrExperimentNotFound
+	}
+	return nil
+}
+
+func (r *experimentRepository) GetByID(id string) (*domain.Experiment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var experiment domain.Experiment
+	filter := bson.M{"_id": id}
+	err := r.collection.FindOne(ctx, filter).Decode(&experiment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrExperimentNotFound
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &experiment, nil
+}
+
+// List returns the experiments with the given status, or all experiments when
+// status is empty, oldest first
+func (r *experimentRepository) List(status domain.ExperimentStatus) ([]*domain.Experiment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer cursor.Close(ctx)
+
+	experiments := []*domain.Experiment{}
+	if err := cursor.All(ctx, &experiments); err != nil {
+		return nil, translateError(err)
+	}
+	return experiments, nil
+}
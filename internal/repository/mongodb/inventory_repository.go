@@ -0,0 +1,130 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"golang-ecommerce-search/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ReserveStock atomically decreases the stock of a product, or of its variant
+// when sku is set, failing with domain.ErrInsufficientStock rather than going
+// below zero. It returns the updated product.
+func (r *productRepository) ReserveStock(productID, sku string, quantity int64) (*domain.Product, error) {
+	return r.adjustStock(productID, sku, bson.M{"$gte": quantity}, func(current string) interface{} {
+		return bson.M{"$subtract": bson.A{current, quantity}}
+	})
+}
+
+// ReleaseStock atomically increases the stock of a product or variant
+func (r *productRepository) ReleaseStock(productID, sku string, quantity int64) (*domain.Product, error) {
+	return r.adjustStock(productID, sku, nil, func(current string) interface{} {
+		return bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{current, 0}}, quantity}}
+	})
+}
+
+// SetStock replaces the stock of a product or variant
+func (r *productRepository) SetStock(productID, sku string, stock int64) (*domain.Product, error) {
+	return r.adjustStock(productID, sku, nil, func(string) interface{} {
+		return bson.M{"$literal": stock}
+	})
+}
+
+// RefreshAvailability recomputes the availability of a product from the
+// current stock of the product and its variants
+func (r *productRepository) RefreshAvailability(productID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(ctx, activeProduct(productID), bson.A{availabilityStage()})
+	return translateError(err)
+}
+
+// adjustStock updates the stock with a pipeline, so the new stock and the
+// availability derived from it are written in one atomic update, and
+// increments the version. stockCond
+// optionally restricts the current stock, and newStock builds the new stock
+// from the path of the current one.
+func (r *productRepository) adjustStock(productID, sku string, stockCond bson.M, newStock func(current string) interface{}) (*domain.Product, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := activeProduct(productID)
+	var set bson.M
+	if sku == "" {
+		if stockCond != nil {
+			filter["stock"] = stockCond
+		}
+		set = bson.M{"stock": newStock("$stock")}
+	} else {
+		match := bson.M{"sku": sku}
+		if stockCond != nil {
+			match["stock"] = stockCond
+		}
+		filter["variants"] = bson.M{"$elemMatch": match}
+		set = bson.M{"variants": bson.M{"$map": bson.M{
+			"input": "$variants",
+			"as":    "variant",
+			"in": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$$variant.sku", bson.M{"$literal": sku}}},
+				bson.M{"$mergeObjects": bson.A{"$$variant", bson.M{"stock": newStock("$$variant.stock")}}},
+				"$$variant",
+			}},
+		}}}
+	}
+	set["updated_at"] = time.Now()
+	set["version"] = bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", 0}}, 1}}
+
+	pipeline := bson.A{bson.M{"$set": set}, availabilityStage()}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var product domain.Product
+	err := r.collection.FindOneAndUpdate(ctx, filter, pipeline, opts).Decode(&product)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, r.stockConflict(ctx, productID, sku)
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &product, nil
+}
+
+// stockConflict explains why a stock update matched nothing
+func (r *productRepository) stockConflict(ctx context.Context, productID, sku string) error {
+	var product domain.Product
+	err := r.collection.FindOne(ctx, activeProduct(productID)).Decode(&product)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return domain.ErrProductNotFound
+	}
+	if err != nil {
+		return translateError(err)
+	}
+	if _, ok := product.StockOf(sku); !ok {
+		return domain.ErrVariantNotFound
+	}
+	return domain.ErrInsufficientStock
+}
+
+// availabilityStage sets the availability to in stock when the product or
+// any of its variants has stock
+func availabilityStage() bson.M {
+	anyVariantInStock := bson.M{"$anyElementTrue": bson.A{bson.M{"$map": bson.M{
+		"input": bson.M{"$ifNull": bson.A{"$variants", bson.A{}}},
+		"as":    "variant",
+		"in":    bson.M{"$gt": bson.A{"$$variant.stock", 0}},
+	}}}}
+
+	return bson.M{"$set": bson.M{"availability": bson.M{"$cond": bson.A{
+		bson.M{"$or": bson.A{
+			bson.M{"$gt": bson.A{bson.M{"$ifNull": bson.A{"$stock", 0}}, 0}},
+			anyVariantInStock,
+		}},
+		domain.AvailabilityInStock,
+		domain.AvailabilityOutOfStock,
+	}}}}
+}
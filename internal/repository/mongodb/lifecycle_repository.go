@@ -0,0 +1,142 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"golang-ecommerce-search/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// List returns products of any status, most recently updated first. Products
+// stored before statuses existed are listed as published.
+func (r *productRepository) List(params domain.ProductListParams) ([]*domain.Product, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"deleted_at": bson.M{"$exists": params.Deleted}}
+	switch params.Status {
+	case "":
+	case domain.StatusPublished:
+		filter["$or"] = bson.A{
+			bson.M{"status": domain.StatusPublished},
+			bson.M{"status": bson.M{"$exists": false}},
+		}
+	default:
+		filter["status"] = params.Status
+	}
+
+	skip := (params.Page - 1) * params.PageSize
+	if skip < 0 {
+		skip = 0
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: -1}}).
+		SetSkip(int64(skip)).
+		SetLimit(int64(params.PageSize)))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer cursor.Close(ctx)
+
+	products := []*domain.Product{}
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, translateError(err)
+	}
+	return products, nil
+}
+
+// UpdateLifecycle sets the status and schedule of a product, clearing
+// schedule dates that are not set, and increments its version. With a
+// version set the update only applies if the stored product is still at that
+// version.
+func (r *productRepository) UpdateLifecycle(productID string, lifecycle domain.Lifecycle, version int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	set := bson.M{"status": lifecycle.Status, "updated_at": time.Now()}
+	unset := bson.M{}
+	if lifecycle.PublishAt != nil {
+		set["publish_at"] = *lifecycle.PublishAt
+	} else {
+		unset["publish_at"] = ""
+	}
+	if lifecycle.UnpublishAt != nil {
+		set["unpublish_at"] = *lifecycle.UnpublishAt
+	} else {
+		unset["unpublish_at"] = ""
+	}
+
+	update := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	filter := activeProduct(productID)
+	if version > 0 {
+		filter["version"] = version
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return translateError(err)
+	}
+	if result.MatchedCount == 0 {
+		return r.updateMismatch(productID)
+	}
+	return nil
+}
+
+// ApplyLifecycleSchedule publishes the drafts whose publish date has passed
+// and archives the published products whose unpublish date has passed. It
+// returns the IDs of the changed products.
+func (r *productRepository) ApplyLifecycleSchedule(now time.Time) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	transitions := []struct {
+		filter bson.M
+		status string
+		field  string
+	}{
+		{bson.M{"status": domain.StatusDraft, "publish_at": bson.M{"$lte": now}, "deleted_at": bson.M{"$exists": false}}, domain.StatusPublished, "publish_at"},
+		{bson.M{"status": bson.M{"$nin": domain.HiddenStatuses}, "unpublish_at": bson.M{"$lte": now}, "deleted_at": bson.M{"$exists": false}}, domain.StatusArchived, "unpublish_at"},
+	}
+
+	var changed []string
+	for _, transition := range transitions {
+		cursor, err := r.collection.Find(ctx, transition.filter, options.Find().SetProjection(bson.M{"_id": 1}))
+		if err != nil {
+			return changed, translateError(err)
+		}
+		var due []struct {
+			ID string `bson:"_id"`
+		}
+		if err := cursor.All(ctx, &due); err != nil {
+			return changed, translateError(err)
+		}
+
+		for _, product := range due {
+			// Repeat the condition so products changed meanwhile are skipped
+			filter := bson.M{"_id": product.ID}
+			for key, value := range transition.filter {
+				filter[key] = value
+			}
+			update := bson.M{
+				"$set":   bson.M{"status": transition.status, "updated_at": now},
+				"$unset": bson.M{transition.field: ""},
+				"$inc":   bson.M{"version": 1},
+			}
+			result, err := r.collection.UpdateOne(ctx, filter, update)
+			if err != nil {
+				return changed, translateError(err)
+			}
+			if result.ModifiedCount > 0 {
+				changed = append(changed, product.ID)
+			}
+		}
+	}
+	return changed, nil
+}
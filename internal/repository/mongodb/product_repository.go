@@ -2,7 +2,7 @@ package mongodb
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"time"
 
 	"golang-ecommerce-search/internal/domain"
@@ -18,9 +18,24 @@ type ProductRepository interface {
 	Update(product *domain.Product) error
 	Delete(id string) error
 	GetByID(id string) (*domain.Product, error)
+	GetByIDs(ids []string) ([]*domain.Product, error)
 	Search(params domain.SearchParams) ([]*domain.Product, error)
 	IncrementViews(id string) error
 	IncrementBuys(id string) error
+	AddVariant(productID string, variant *domain.ProductVariant) error
+	UpdateVariant(productID string, variant *domain.ProductVariant) error
+	DeleteVariant(productID, sku string) error
+	ReserveStock(productID, sku string, quantity int64) (*domain.Product, error)
+	ReleaseStock(productID, sku string, quantity int64) (*domain.Product, error)
+	SetStock(productID, sku string, stock int64) (*domain.Product, error)
+	RefreshAvailability(productID string) error
+	List(params domain.ProductListParams) ([]*domain.Product, error)
+	UpdateLifecycle(productID string, lifecycle domain.Lifecycle, version int64) error
+	ApplyLifecycleSchedule(now time.Time) ([]string, error)
+	Restore(id string) (*domain.Product, error)
+	PurgeDeleted(before time.Time) (int64, error)
+	BulkWrite(writes []ProductWrite) (map[int]error, error)
+	EnsureVersions() (int64, error)
 }
 
 type productRepository struct {
@@ -43,19 +58,37 @@ func (r *productRepository) Create(product *domain.Product) error {
 	product.ID = productID.String()
 	product.CreatedAt = time.Now()
 	product.UpdatedAt = time.Now()
+	product.Version = 1
 
 	_, err := r.collection.InsertOne(ctx, product)
-	return err
+	return translateError(err)
 }
 
+// Update replaces the editable fields of a product. With a version set the
+// update only applies if the stored product is still at that version.
 func (r *productRepository) Update(product *domain.Product) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	product.UpdatedAt = time.Now()
 
-	filter := bson.M{"_id": product.ID}
-	update := bson.M{
+	filter := activeProduct(product.ID)
+	if product.Version > 0 {
+		filter["version"] = product.Version
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, editableUpdate(product))
+	if err != nil {
+		return translateError(err)
+	}
+	if result.MatchedCount == 0 {
+		return r.updateMismatch(product.ID)
+	}
+	return nil
+}
+
+// editableUpdate sets the editable fields of a product and increments its version
+func editableUpdate(product *domain.Product) bson.M {
+	return bson.M{
 		"$set": bson.M{
 			"name":        product.Name,
 			"description": product.Description,
@@ -65,19 +98,51 @@ func (r *productRepository) Update(product *domain.Product) error {
 			"tags":        product.Tags,
 			"updated_at":  product.UpdatedAt,
 		},
+		"$inc": bson.M{"version": 1},
 	}
+}
 
-	_, err := r.collection.UpdateOne(ctx, filter, update)
-	return err
+// updateMismatch explains why a versioned update matched no product: it does
+// not exist, or it was changed since the expected version
+func (r *productRepository) updateMismatch(id string) error {
+	current, err := r.GetByID(id)
+	if err != nil {
+		return translateError(err)
+	}
+	return &domain.VersionConflictError{Current: current.Version}
 }
 
+// EnsureVersions gives products stored before versioning version 1, so the
+// ETag they are served with can be sent back in If-Match. It returns the
+// number of products changed.
+func (r *productRepository) EnsureVersions() (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	filter := bson.M{"version": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"version": 1}})
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return result.ModifiedCount, nil
+}
+
+// Delete soft deletes a product by marking it deleted; it is purged after
+// the retention period
 func (r *productRepository) Delete(id string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	filter := bson.M{"_id": id}
-	_, err := r.collection.DeleteOne(ctx, filter)
-	return err
+	now := time.Now()
+	update := bson.M{"$set": bson.M{"deleted_at": now, "updated_at": now}}
+	result, err := r.collection.UpdateOne(ctx, activeProduct(id), update)
+	if err != nil {
+		return translateError(err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrProductNotFound
+	}
+	return nil
 }
 
 func (r *productRepository) GetByID(id string) (*domain.Product, error) {
@@ -85,14 +150,37 @@ func (r *productRepository) GetByID(id string) (*domain.Product, error) {
 	defer cancel()
 
 	var product domain.Product
-	filter := bson.M{"_id": id}
+	filter := activeProduct(id)
 	err := r.collection.FindOne(ctx, filter).Decode(&product)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrProductNotFound
+	}
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return &product, nil
 }
 
+// GetByIDs returns the products with the given IDs in no particular order.
+// IDs that do not exist are skipped.
+func (r *productRepository) GetByIDs(ids []string) ([]*domain.Product, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": bson.M{"$in": ids}, "deleted_at": bson.M{"$exists": false}}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var products []*domain.Product
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, translateError(err)
+	}
+	return products, nil
+}
+
 func (r *productRepository) Search(params domain.SearchParams) ([]*domain.Product, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -100,8 +188,10 @@ func (r *productRepository) Search(params domain.SearchParams) ([]*domain.Produc
 	// Build the filter
 	filter := bson.M{}
 
-	// Add text search if query is provided
-	if params.Query != "" {
+	// Add the advanced query expression, or the text search if a query is provided
+	if params.Expression != nil {
+		filter["$and"] = []bson.M{compileExpression(params.Expression)}
+	} else if params.Query != "" {
 		filter["$or"] = []bson.M{
 			{"name": bson.M{"$regex": params.Query, "$options": "i"}},
 			{"description": bson.M{"$regex": params.Query, "$options": "i"}},
@@ -110,6 +200,10 @@ func (r *productRepository) Search(params domain.SearchParams) ([]*domain.Produc
 		}
 	}
 
+	// Only published products that are not deleted are searchable
+	filter["status"] = bson.M{"$nin": domain.HiddenStatuses}
+	filter["deleted_at"] = bson.M{"$exists": false}
+
 	// Add category filter if provided
 	if len(params.Categories) > 0 {
 		filter["category"] = bson.M{"$in": params.Categories}
@@ -120,19 +214,30 @@ func (r *productRepository) Search(params domain.SearchParams) ([]*domain.Produc
 		filter["brand"] = bson.M{"$in": params.Brands}
 	}
 
-	// Build the sort options
-	sort := bson.M{}
-	switch params.SortBy {
-	case "views":
-		sort["views"] = -1
-	case "buys":
-		sort["buys"] = -1
-	default:
-		// Default sort by views and buys
-		sort["views"] = -1
-		sort["buys"] = -1
+	// Add price range filter if provided
+	if params.MinPrice != nil || params.MaxPrice != nil {
+		priceRange := bson.M{}
+		if params.MinPrice != nil {
+			priceRange["$gte"] = *params.MinPrice
+		}
+		if params.MaxPrice != nil {
+			priceRange["$lte"] = *params.MaxPrice
+		}
+		filter["price"] = priceRange
 	}
 
+	// Add variant attribute and stock filters if provided
+	if variants := variantFilter(params); variants != nil {
+		filter["variants"] = variants
+	}
+	// Products stored before availability existed have none and stay visible
+	if params.InStock {
+		filter["availability"] = bson.M{"$ne": domain.AvailabilityOutOfStock}
+	}
+
+	// Build the sort options
+	sort := buildSort(params.Sort)
+
 	// Calculate pagination
 	skip := (params.Page - 1) * params.PageSize
 	if skip < 0 {
@@ -145,13 +250,13 @@ func (r *productRepository) Search(params domain.SearchParams) ([]*domain.Produc
 		SetSkip(int64(skip)).
 		SetLimit(int64(params.PageSize)))
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	defer cursor.Close(ctx)
 
 	var products []*domain.Product
 	if err := cursor.All(ctx, &products); err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	return products, nil
@@ -161,7 +266,7 @@ func (r *productRepository) IncrementViews(id string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	filter := bson.M{"_id": id}
+	filter := activeProduct(id)
 	update := bson.M{
 		"$inc": bson.M{
 			"views": 1,
@@ -170,12 +275,12 @@ func (r *productRepository) IncrementViews(id string) error {
 
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return err
+		return translateError(err)
 	}
 
 	// Check if document was actually updated
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("product with ID %s not found", id)
+		return domain.ErrProductNotFound
 	}
 
 	return nil
@@ -185,7 +290,7 @@ func (r *productRepository) IncrementBuys(id string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	filter := bson.M{"_id": id}
+	filter := activeProduct(id)
 	update := bson.M{
 		"$inc": bson.M{
 			"buys": 1,
@@ -194,13 +299,58 @@ func (r *productRepository) IncrementBuys(id string) error {
 
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return err
+		return translateError(err)
 	}
 
 	// Check if document was actually updated
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("product with ID %s not found", id)
+		return domain.ErrProductNotFound
 	}
 
 	return nil
 }
+
+// sortFields maps domain sort fields to document fields. Relevance has no
+// meaning without a text score, so it falls back to popularity.
+var sortFields = map[string]string{
+	domain.SortFieldPrice:     "price",
+	domain.SortFieldCreatedAt: "created_at",
+	domain.SortFieldName:      "name",
+	domain.SortFieldViews:     "views",
+	domain.SortFieldBuys:      "buys",
+}
+
+func buildSort(fields []domain.SortField) bson.D {
+	sort := bson.D{}
+	seen := make(map[string]bool)
+	add := func(field string, order int) {
+		if !seen[field] {
+			seen[field] = true
+			sort = append(sort, bson.E{Key: field, Value: order})
+		}
+	}
+
+	for _, f := range fields {
+		if f.Field == domain.SortFieldRelevance {
+			add("views", -1)
+			add("buys", -1)
+			continue
+		}
+		field, ok := sortFields[f.Field]
+		if !ok {
+			continue
+		}
+		order := 1
+		if f.Order == domain.SortDesc {
+			order = -1
+		}
+		add(field, order)
+	}
+
+	if len(sort) == 0 {
+		// Default sort by views and buys
+		add("views", -1)
+		add("buys", -1)
+	}
+	return sort
+}
@@ -0,0 +1,58 @@
+package mongodb
+
+import (
+	"regexp"
+
+	"golang-ecommerce-search/internal/querylang"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// compileExpression compiles an advanced query AST into a MongoDB filter.
+// Text is matched case-insensitively as a literal substring.
+func compileExpression(node querylang.Node) bson.M {
+	switch n := node.(type) {
+	case *querylang.And:
+		return bson.M{"$and": compileChildren(n.Children)}
+	case *querylang.Or:
+		return bson.M{"$or": compileChildren(n.Children)}
+	case *querylang.Not:
+		return bson.M{"$nor": []bson.M{compileExpression(n.Child)}}
+	case *querylang.Term:
+		pattern := textPattern(n.Text)
+		return bson.M{"$or": []bson.M{
+			{"name": pattern},
+			{"description": pattern},
+			{"category": pattern},
+			{"brand": pattern},
+			{"tags": pattern},
+		}}
+	case *querylang.Match:
+		return bson.M{n.Field: textPattern(n.Text)}
+	case *querylang.Compare:
+		ops := map[querylang.Operator]string{
+			querylang.OpEq:  "$eq",
+			querylang.OpLt:  "$lt",
+			querylang.OpLte: "$lte",
+			querylang.OpGt:  "$gt",
+			querylang.OpGte: "$gte",
+		}
+		return bson.M{n.Field: bson.M{ops[n.Op]: n.Value}}
+	case *querylang.Range:
+		return bson.M{n.Field: bson.M{"$gte": n.From, "$lte": n.To}}
+	}
+	// Match nothing for unknown nodes
+	return bson.M{"_id": bson.M{"$exists": false}}
+}
+
+func compileChildren(children []querylang.Node) []bson.M {
+	filters := make([]bson.M, len(children))
+	for i, child := range children {
+		filters[i] = compileExpression(child)
+	}
+	return filters
+}
+
+func textPattern(text string) bson.M {
+	return bson.M{"$regex": regexp.QuoteMeta(text), "$options": "i"}
+}
@@ -0,0 +1,132 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"golang-ecommerce-search/internal/domain"
+	"golang-ecommerce-search/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RuleRepository interface {
+	Create(rule *domain.Rule) error
+	Update(rule *domain.Rule) error
+	Delete(id string) error
+	GetByID(id string) (*domain.Rule, error)
+	List(enabledOnly bool) ([]*domain.Rule, error)
+}
+
+type ruleRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRuleRepository(db *mongo.Database, collectionName string) RuleRepository {
+	collection := db.Collection(collectionName)
+	return &ruleRepository{
+		collection: collection,
+	}
+}
+
+func (r *ruleRepository) Create(rule *domain.Rule) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rule.ID = model.NewID().String()
+	rule.CreatedAt = time.Now()
+	rule.UpdatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, rule)
+	return translateError(err)
+}
+
+func (r *ruleRepository) Update(rule *domain.Rule) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rule.UpdatedAt = time.Now()
+
+	filter := bson.M{"_id": rule.ID}
+	update := bson.M{
+		"$set": bson.M{
+			"name":       rule.Name,
+			"enabled":    rule.Enabled,
+			"priority":   rule.Priority,
+			"conditions": rule.Conditions,
+			"actions":    rule.Actions,
+			"starts_at":  rule.StartsAt,
+			"ends_at":    rule.EndsAt,
+			"updated_at": rule.UpdatedAt,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return translateError(err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrRuleNotFound
+	}
+	return nil
+}
+
+func (r *ruleRepository) Delete(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id}
+	result, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return translateError(err)
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrRuleNotFound
+	}
+	return nil
+}
+
+func (r *ruleRepository) GetByID(id string) (*domain.Rule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var rule domain.Rule
+	filter := bson.M{"_id": id}
+	err := r.collection.FindOne(ctx, filter).Decode(&rule)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrRuleNotFound
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &rule, nil
+}
+
+// List returns the rules, or only the enabled ones, ordered by descending priority
+func (r *ruleRepository) List(enabledOnly bool) ([]*domain.Rule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if enabledOnly {
+		filter["enabled"] = true
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{
+		{Key: "priority", Value: -1},
+		{Key: "created_at", Value: 1},
+	}))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer cursor.Close(ctx)
+
+	rules := []*domain.Rule{}
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, translateError(err)
+	}
+	return rules, nil
+}
@@ -0,0 +1,33 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"golang-ecommerce-search/internal/domain"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type SearchEventRepository interface {
+	Create(event *domain.SearchEvent) error
+}
+
+type searchEventRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSearchEventRepository(db *mongo.Database, collectionName string) SearchEventRepository {
+	collection := db.Collection(collectionName)
+	return &searchEventRepository{
+		collection: collection,
+	}
+}
+
+func (r *searchEventRepository) Create(event *domain.SearchEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(ctx, event)
+	return translateError(err)
+}
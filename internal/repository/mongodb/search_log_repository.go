@@ -0,0 +1,229 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"golang-ecommerce-search/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SearchLogRepository interface {
+	Create(log *domain.SearchLog) error
+	TopQueries(query domain.AnalyticsQuery) ([]*domain.QueryStats, error)
+	ZeroResultQueries(query domain.AnalyticsQuery) ([]*domain.QueryStats, error)
+	LowCTRQueries(query domain.AnalyticsQuery) ([]*domain.QueryStats, error)
+	RecordClick(searchID string, position int) error
+	RecordConversion(searchID string) error
+	RankingStats(query domain.AnalyticsQuery) ([]*domain.RankingStats, error)
+}
+
+type searchLogRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSearchLogRepository(db *mongo.Database, collectionName string) SearchLogRepository {
+	collection := db.Collection(collectionName)
+	return &searchLogRepository{
+		collection: collection,
+	}
+}
+
+// Create stores a search log. Search events may be processed before the log
+// itself, so the log is upserted and the interaction counters are left intact.
+func (r *searchLogRepository) Create(log *domain.SearchLog) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	data, err := bson.Marshal(log)
+	if err != nil {
+		return translateError(err)
+	}
+
+	var fields bson.M
+	if err := bson.Unmarshal(data, &fields); err != nil {
+		return translateError(err)
+	}
+	delete(fields, "_id")
+	delete(fields, "clicks")
+	delete(fields, "conversions")
+	delete(fields, "first_click_position")
+
+	filter := bson.M{"_id": log.ID}
+	update := bson.M{"$set": fields}
+	_, err = r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return translateError(err)
+}
+
+// RecordClick counts a click on the search and keeps the best clicked position
+func (r *searchLogRepository) RecordClick(searchID string, position int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": searchID}
+	update := bson.M{
+		"$inc": bson.M{"clicks": 1},
+		"$min": bson.M{"first_click_position": position},
+	}
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return translateError(err)
+}
+
+// RecordConversion counts a conversion attributed to the search
+func (r *searchLogRepository) RecordConversion(searchID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": searchID}
+	update := bson.M{"$inc": bson.M{"conversions": 1}}
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return translateError(err)
+}
+
+// TopQueries returns the most searched queries
+func (r *searchLogRepository) TopQueries(query domain.AnalyticsQuery) ([]*domain.QueryStats, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: matchRange(query)}},
+		groupByQuery(),
+		withCTR(),
+		{{Key: "$sort", Value: bson.D{{Key: "searches", Value: -1}}}},
+		{{Key: "$limit", Value: query.Limit}},
+	}
+	return r.aggregate(pipeline)
+}
+
+// ZeroResultQueries returns the queries that most often returned nothing.
+// Only first pages are considered so paging past the end is not counted, and
+// redirected searches are skipped since they intentionally return no products.
+func (r *searchLogRepository) ZeroResultQueries(query domain.AnalyticsQuery) ([]*domain.QueryStats, error) {
+	match := matchRange(query)
+	match["result_count"] = 0
+	match["page"] = 1
+	match["redirect_url"] = bson.M{"$exists": false}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		groupByQuery(),
+		withCTR(),
+		{{Key: "$sort", Value: bson.D{{Key: "searches", Value: -1}}}},
+		{{Key: "$limit", Value: query.Limit}},
+	}
+	return r.aggregate(pipeline)
+}
+
+// LowCTRQueries returns the queries with the lowest click-through rate among
+// those searched at least MinSearches times
+func (r *searchLogRepository) LowCTRQueries(query domain.AnalyticsQuery) ([]*domain.QueryStats, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: matchRange(query)}},
+		groupByQuery(),
+		withCTR(),
+		{{Key: "$match", Value: bson.M{"searches": bson.M{"$gte": query.MinSearches}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "ctr", Value: 1}, {Key: "searches", Value: -1}}}},
+		{{Key: "$limit", Value: query.Limit}},
+	}
+	return r.aggregate(pipeline)
+}
+
+// RankingStats reports CTR, conversion rate and mean reciprocal rank of the
+// first click, grouped by query, ranking profile or experiment variant
+func (r *searchLogRepository) RankingStats(query domain.AnalyticsQuery) ([]*domain.RankingStats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	match := bson.M{"created_at": bson.M{"$gte": query.From, "$lt": query.To}}
+	if query.ExperimentID != "" {
+		match["experiment_id"] = query.ExperimentID
+	}
+
+	var groupKey interface{}
+	switch query.GroupBy {
+	case domain.GroupByQuery:
+		match["query"] = bson.M{"$ne": ""}
+		groupKey = "$query"
+	case domain.GroupByVariant:
+		match["variant"] = bson.M{"$exists": true}
+		groupKey = "$variant"
+	default:
+		groupKey = bson.M{"$ifNull": bson.A{"$ranking_profile", domain.DefaultRankingProfile}}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         groupKey,
+			"searches":    bson.M{"$sum": 1},
+			"clicks":      bson.M{"$sum": "$clicks"},
+			"conversions": bson.M{"$sum": "$conversions"},
+			"mrr": bson.M{"$avg": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$first_click_position", 0}},
+				bson.M{"$divide": bson.A{1, "$first_click_position"}},
+				0,
+			}}},
+		}}},
+		{{Key: "$addFields", Value: bson.M{
+			"ctr":             bson.M{"$divide": bson.A{"$clicks", "$searches"}},
+			"conversion_rate": bson.M{"$divide": bson.A{"$conversions", "$searches"}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "searches", Value: -1}}}},
+		{{Key: "$limit", Value: query.Limit}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer cursor.Close(ctx)
+
+	stats := []*domain.RankingStats{}
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, translateError(err)
+	}
+	return stats, nil
+}
+
+func (r *searchLogRepository) aggregate(pipeline mongo.Pipeline) ([]*domain.QueryStats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer cursor.Close(ctx)
+
+	stats := []*domain.QueryStats{}
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, translateError(err)
+	}
+	return stats, nil
+}
+
+// matchRange matches the non-empty queries logged within the time range
+func matchRange(query domain.AnalyticsQuery) bson.M {
+	return bson.M{
+		"query":      bson.M{"$ne": ""},
+		"created_at": bson.M{"$gte": query.From, "$lt": query.To},
+	}
+}
+
+// groupByQuery aggregates search logs per normalized query
+func groupByQuery() bson.D {
+	return bson.D{{Key: "$group", Value: bson.M{
+		"_id":              "$query",
+		"searches":         bson.M{"$sum": 1},
+		"zero_results":     bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$result_count", 0}}, 1, 0}}},
+		"avg_result_count": bson.M{"$avg": "$result_count"},
+		"clicks":           bson.M{"$sum": "$clicks"},
+	}}}
+}
+
+// withCTR derives the click-through rate of each grouped query
+func withCTR() bson.D {
+	return bson.D{{Key: "$addFields", Value: bson.M{
+		"ctr": bson.M{"$divide": bson.A{"$clicks", "$searches"}},
+	}}}
+}
@@ -0,0 +1,112 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"golang-ecommerce-search/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// AddVariant appends a variant to a product unless its SKU is already taken.
+// Like the other variant changes it increments the version of the product.
+func (r *productRepository) AddVariant(productID string, variant *domain.ProductVariant) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := activeProduct(productID)
+	filter["variants.sku"] = bson.M{"$ne": variant.SKU}
+	update := bson.M{
+		"$push": bson.M{"variants": variant},
+		"$set":  bson.M{"updated_at": time.Now()},
+		"$inc":  bson.M{"version": 1},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return translateError(err)
+	}
+	if result.MatchedCount == 0 {
+		return r.missingProductOr(ctx, productID, domain.ErrDuplicateSKU)
+	}
+	return nil
+}
+
+// UpdateVariant replaces the variant with the same SKU
+func (r *productRepository) UpdateVariant(productID string, variant *domain.ProductVariant) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := activeProduct(productID)
+	filter["variants.sku"] = variant.SKU
+	update := bson.M{
+		"$set": bson.M{
+			"variants.$": variant,
+			"updated_at": time.Now(),
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return translateError(err)
+	}
+	if result.MatchedCount == 0 {
+		return r.missingProductOr(ctx, productID, domain.ErrVariantNotFound)
+	}
+	return nil
+}
+
+// DeleteVariant removes the variant with the given SKU
+func (r *productRepository) DeleteVariant(productID, sku string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := activeProduct(productID)
+	filter["variants.sku"] = sku
+	update := bson.M{
+		"$pull": bson.M{"variants": bson.M{"sku": sku}},
+		"$set":  bson.M{"updated_at": time.Now()},
+		"$inc":  bson.M{"version": 1},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return translateError(err)
+	}
+	if result.MatchedCount == 0 {
+		return r.missingProductOr(ctx, productID, domain.ErrVariantNotFound)
+	}
+	return nil
+}
+
+// missingProductOr explains why a variant update matched nothing: either the
+// product does not exist, or the variant condition failed with err
+func (r *productRepository) missingProductOr(ctx context.Context, productID string, err error) error {
+	count, countErr := r.collection.CountDocuments(ctx, activeProduct(productID))
+	if countErr != nil {
+		return countErr
+	}
+	if count == 0 {
+		return domain.ErrProductNotFound
+	}
+	return translateError(err)
+}
+
+// variantFilter matches products with a variant having all the attributes,
+// and in stock when required
+func variantFilter(params domain.SearchParams) bson.M {
+	if len(params.VariantAttributes) == 0 {
+		return nil
+	}
+
+	match := bson.M{}
+	for name, value := range params.VariantAttributes {
+		match["attributes."+name] = value
+	}
+	if params.InStock {
+		match["stock"] = bson.M{"$gt": 0}
+	}
+	return bson.M{"$elemMatch": match}
+}
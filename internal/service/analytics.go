@@ -0,0 +1,102 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"golang-ecommerce-search/internal/config"
+	"golang-ecommerce-search/internal/domain"
+	"golang-ecommerce-search/internal/model"
+	mongo "golang-ecommerce-search/internal/repository/mongodb"
+	"golang-ecommerce-search/pkg/kafka"
+)
+
+type AnalyticsService interface {
+	domain.AnalyticsService
+}
+
+type analyticsService struct {
+	searchLogRepo   mongo.SearchLogRepository
+	searchEventRepo mongo.SearchEventRepository
+	producer        *kafka.Producer
+	config          *config.Config
+}
+
+func NewAnalyticsService(searchLogRepo mongo.SearchLogRepository, searchEventRepo mongo.SearchEventRepository, producer *kafka.Producer, cfg *config.Config) AnalyticsService {
+	return &analyticsService{
+		searchLogRepo:   searchLogRepo,
+		searchEventRepo: searchEventRepo,
+		producer:        producer,
+		config:          cfg,
+	}
+}
+
+func (s *analyticsService) TopQueries(query domain.AnalyticsQuery) ([]*domain.QueryStats, error) {
+	stats, err := s.searchLogRepo.TopQueries(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate top queries in MongoDB: %w", err)
+	}
+	return stats, nil
+}
+
+func (s *analyticsService) ZeroResultQueries(query domain.AnalyticsQuery) ([]*domain.QueryStats, error) {
+	stats, err := s.searchLogRepo.ZeroResultQueries(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate zero-result queries in MongoDB: %w", err)
+	}
+	return stats, nil
+}
+
+func (s *analyticsService) LowCTRQueries(query domain.AnalyticsQuery) ([]*domain.QueryStats, error) {
+	stats, err := s.searchLogRepo.LowCTRQueries(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate low CTR queries in MongoDB: %w", err)
+	}
+	return stats, nil
+}
+
+func (s *analyticsService) RankingStats(query domain.AnalyticsQuery) ([]*domain.RankingStats, error) {
+	stats, err := s.searchLogRepo.RankingStats(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate ranking stats in MongoDB: %w", err)
+	}
+	return stats, nil
+}
+
+// RecordSearchEvent publishes a click or conversion event to be joined with
+// its search log by the worker
+func (s *analyticsService) RecordSearchEvent(event *domain.SearchEvent) error {
+	event.ID = model.NewID().String()
+	event.CreatedAt = time.Now()
+
+	return publishEvent(s.producer, s.config.Kafka.Topic.SearchEvent, event)
+}
+
+// OnSearchLogged stores a search log published by the API
+func (s *analyticsService) OnSearchLogged(log *domain.SearchLog) error {
+	if err := s.searchLogRepo.Create(log); err != nil {
+		return fmt.Errorf("failed to store search log in MongoDB: %w", err)
+	}
+	return nil
+}
+
+// OnSearchEvent stores a search event and updates the counters of its search log
+func (s *analyticsService) OnSearchEvent(event *domain.SearchEvent) error {
+	if err := s.searchEventRepo.Create(event); err != nil {
+		return fmt.Errorf("failed to store search event in MongoDB: %w", err)
+	}
+
+	var err error
+	switch event.Type {
+	case domain.SearchEventClick:
+		err = s.searchLogRepo.RecordClick(event.SearchID, event.Position)
+	case domain.SearchEventConversion:
+		err = s.searchLogRepo.RecordConversion(event.SearchID)
+	default:
+		return fmt.Errorf("unknown search event type %q", event.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update search log in MongoDB: %w", err)
+	}
+	return nil
+}
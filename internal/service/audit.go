@@ -0,0 +1,78 @@
+package service
+
+import (
+	"fmt"
+	"log"
+
+	"golang-ecommerce-search/internal/domain"
+)
+
+// recordAudit stores a change to a product in its history. The change itself
+// has already been made, so a failure is logged rather than returned.
+func (s *productService) recordAudit(action string, before, after *domain.Product, audit domain.AuditContext) {
+	if s.auditRepo == nil {
+		return
+	}
+
+	entry := &domain.AuditEntry{
+		Action:    action,
+		Actor:     audit.Actor,
+		RequestID: audit.RequestID,
+		Changes:   domain.DiffProducts(before, after),
+		Snapshot:  after,
+	}
+	if after != nil {
+		entry.ProductID = after.ID
+	} else if before != nil {
+		entry.ProductID = before.ID
+		entry.Snapshot = before
+	}
+	s.storeAudit(entry)
+}
+
+func (s *productService) storeAudit(entry *domain.AuditEntry) {
+	if err := s.auditRepo.Create(entry); err != nil {
+		log.Printf("Failed to record %s of product %s: %v", entry.Action, entry.ProductID, err)
+	}
+}
+
+// ProductHistory returns the most recent changes to a product, newest first
+func (s *productService) ProductHistory(id string, limit int) ([]*domain.AuditEntry, error) {
+	entries, err := s.auditRepo.ListByProduct(id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product history from MongoDB: %w", err)
+	}
+	return entries, nil
+}
+
+// RevertProduct restores the editable fields of a product to a revision of
+// its history. The revert is recorded as a new revision.
+func (s *productService) RevertProduct(id string, revision int64, audit domain.AuditContext) (*domain.Product, error) {
+	entry, err := s.auditRepo.GetRevision(id, revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product revision from MongoDB: %w", err)
+	}
+
+	before, err := s.mongoRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product from MongoDB: %w", err)
+	}
+
+	reverted := *before
+	reverted.Revert(entry.Snapshot)
+	after, err := s.saveUpdate(before, &reverted)
+	if err != nil {
+		return nil, err
+	}
+
+	s.storeAudit(&domain.AuditEntry{
+		ProductID:  id,
+		Action:     domain.AuditRevert,
+		Actor:      audit.Actor,
+		RequestID:  audit.RequestID,
+		Changes:    domain.DiffProducts(before, after),
+		RevertedTo: revision,
+		Snapshot:   after,
+	})
+	return after, nil
+}
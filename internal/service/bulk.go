@@ -0,0 +1,215 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"golang-ecommerce-search/internal/domain"
+	mongo "golang-ecommerce-search/internal/repository/mongodb"
+)
+
+// BulkProducts executes a batch of upserts and deletes, inserting the new
+// products with a single bulk write. Operations are validated and checked against the stored products
+// first, and every operation reports its own outcome, records its own audit
+// entry and publishes its own event, so a failing operation does not fail
+// the batch.
+func (s *productService) BulkProducts(operations []domain.BulkOperation, audit domain.AuditContext) (*domain.BulkResult, error) {
+	result := &domain.BulkResult{Items: make([]*domain.BulkItemResult, len(operations))}
+	for i := range operations {
+		result.Items[i] = &domain.BulkItemResult{Index: i, Action: operations[i].Action, ID: operations[i].ProductID()}
+	}
+
+	existing, err := s.existingProducts(operations)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prepare the writes of the valid operations, remembering their items
+	var writes []mongo.ProductWrite
+	var items []*domain.BulkItemResult
+	changed := make(map[string]bool, len(operations))
+	for i := range operations {
+		item := result.Items[i]
+		write, err := s.prepareWrite(&operations[i], existing)
+		if err == nil && item.ID != "" && changed[item.ID] {
+			err = domain.Invalidf("the product is changed by an earlier operation of the batch")
+		}
+		if err != nil {
+			failBulkItem(item, err)
+			continue
+		}
+		if item.ID != "" {
+			changed[item.ID] = true
+		}
+		writes = append(writes, write)
+		items = append(items, item)
+	}
+
+	failed, err := s.mongoRepo.BulkWrite(writes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write products to MongoDB: %w", err)
+	}
+
+	stored, err := s.storedProducts(writes, failed)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, write := range writes {
+		item := items[i]
+		if err := failed[i]; err != nil {
+			failBulkItem(item, err)
+			continue
+		}
+		s.completeWrite(item, write, existing, stored, audit)
+	}
+
+	for _, item := range result.Items {
+		switch item.Status {
+		case domain.BulkCreated:
+			result.Created++
+		case domain.BulkUpdated:
+			result.Updated++
+		case domain.BulkDeleted:
+			result.Deleted++
+		default:
+			result.Failed++
+		}
+	}
+	return result, nil
+}
+
+// existingProducts returns the stored products the operations refer to, by ID
+func (s *productService) existingProducts(operations []domain.BulkOperation) (map[string]*domain.Product, error) {
+	var ids []string
+	for i := range operations {
+		if id := operations[i].ProductID(); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return s.productsByID(ids)
+}
+
+// storedProducts returns the products created and updated by the successful writes
+func (s *productService) storedProducts(writes []mongo.ProductWrite, failed map[int]error) (map[string]*domain.Product, error) {
+	var ids []string
+	for i, write := range writes {
+		if failed[i] == nil && write.Kind != mongo.WriteDelete {
+			ids = append(ids, write.Product.ID)
+		}
+	}
+	return s.productsByID(ids)
+}
+
+func (s *productService) productsByID(ids []string) (map[string]*domain.Product, error) {
+	products := make(map[string]*domain.Product, len(ids))
+	if len(ids) == 0 {
+		return products, nil
+	}
+
+	found, err := s.mongoRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products from MongoDB: %w", err)
+	}
+	for _, product := range found {
+		products[product.ID] = product
+	}
+	return products, nil
+}
+
+// prepareWrite validates an operation and turns it into a write: upserts of
+// existing products update their editable fields, other upserts create the
+// product with the given ID, and deletes require the product to exist
+func (s *productService) prepareWrite(operation *domain.BulkOperation, existing map[string]*domain.Product) (mongo.ProductWrite, error) {
+	id := operation.ProductID()
+	switch operation.Action {
+	case domain.BulkUpsert:
+		product := operation.Product
+		if product == nil {
+			return mongo.ProductWrite{}, domain.Invalidf("product is required")
+		}
+
+		if _, ok := existing[id]; ok {
+			product.ID = id
+			if err := product.Validate(s.config.Products.Categories, s.config.Products.MaxPrice); err != nil {
+				return mongo.ProductWrite{}, err
+			}
+			return mongo.ProductWrite{Kind: mongo.WriteUpdate, ID: id, Product: product}, nil
+		}
+
+		if err := product.ValidateNew(s.config.Products.Categories, s.config.Products.MaxPrice); err != nil {
+			return mongo.ProductWrite{}, err
+		}
+		product.ID = id
+		product.RefreshAvailability()
+		product.SetLifecycle(product.Lifecycle())
+		return mongo.ProductWrite{Kind: mongo.WriteCreate, ID: id, Product: product}, nil
+
+	case domain.BulkDelete:
+		if id == "" {
+			return mongo.ProductWrite{}, domain.Invalidf("id is required")
+		}
+		if _, ok := existing[id]; !ok {
+			return mongo.ProductWrite{}, domain.ErrProductNotFound
+		}
+		return mongo.ProductWrite{Kind: mongo.WriteDelete, ID: id}, nil
+	}
+	return mongo.ProductWrite{}, domain.Invalidf("action must be %s or %s", domain.BulkUpsert, domain.BulkDelete)
+}
+
+// completeWrite reports a successful write, records it and publishes the
+// event the worker indexes the product with. The product is already stored,
+// so a failure to publish is logged rather than reported.
+func (s *productService) completeWrite(item *domain.BulkItemResult, write mongo.ProductWrite, existing, stored map[string]*domain.Product, audit domain.AuditContext) {
+	var err error
+	switch write.Kind {
+	case mongo.WriteCreate:
+		item.Status = domain.BulkCreated
+		item.ID = write.Product.ID
+		if after, ok := stored[item.ID]; ok {
+			s.recordAudit(domain.AuditCreate, nil, after, audit)
+			err = s.publishEvent(s.config.Kafka.Topic.ProductCreated, after)
+		}
+	case mongo.WriteUpdate:
+		item.Status = domain.BulkUpdated
+		before := existing[item.ID]
+		if after, ok := stored[item.ID]; ok {
+			s.recordAudit(domain.AuditUpdate, before, after, audit)
+			err = s.publishEvent(s.config.Kafka.Topic.ProductUpdated, domain.NewProductChange(before, after))
+		}
+	case mongo.WriteDelete:
+		item.Status = domain.BulkDeleted
+		s.recordAudit(domain.AuditDelete, existing[item.ID], nil, audit)
+		err = s.publishEvent(s.config.Kafka.Topic.ProductDeleted, item.ID)
+	}
+	if err != nil {
+		log.Printf("Failed to publish %s of product %s: %v", item.Status, item.ID, err)
+	}
+}
+
+// failBulkItem reports why an operation failed. Domain errors are reported
+// with their kind and message; other errors are logged and reported as
+// internal errors without details.
+func failBulkItem(item *domain.BulkItemResult, err error) {
+	item.Status = domain.BulkFailed
+
+	var domainErr *domain.Error
+	if !errors.As(err, &domainErr) {
+		log.Printf("Bulk %s of product %s failed: %v", item.Action, item.ID, err)
+		item.Code = domain.ErrorInternal
+		item.Message = "internal error"
+		return
+	}
+
+	item.Code = domainErr.Kind
+	item.Message = domainErr.Message
+	var fieldErrs domain.FieldErrors
+	if errors.As(err, &fieldErrs) {
+		item.Fields = fieldErrs
+	}
+	var conflict *domain.VersionConflictError
+	if errors.As(err, &conflict) {
+		item.CurrentVersion = conflict.Current
+	}
+}
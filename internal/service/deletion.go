@@ -0,0 +1,62 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"golang-ecommerce-search/internal/domain"
+)
+
+const (
+	// defaultDeletedRetention is how long soft deleted products are kept when
+	// no retention is configured
+	defaultDeletedRetention = 30 * 24 * time.Hour
+	// purgeInterval is how often the worker purges expired deleted products
+	purgeInterval = time.Hour
+)
+
+// RestoreProduct undoes a soft delete and publishes the product again so the
+// worker re-indexes it
+func (s *productService) RestoreProduct(id string, audit domain.AuditContext) (*domain.Product, error) {
+	product, err := s.mongoRepo.Restore(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore product in MongoDB: %w", err)
+	}
+
+	s.recordAudit(domain.AuditRestore, nil, product, audit)
+	if err := s.publishEvent(s.config.Kafka.Topic.ProductCreated, product); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// PurgeDeletedProducts permanently removes products soft deleted longer than
+// the retention period
+func (s *productService) PurgeDeletedProducts() error {
+	retention := s.config.Products.DeletedRetention
+	if retention <= 0 {
+		retention = defaultDeletedRetention
+	}
+
+	purged, err := s.mongoRepo.PurgeDeleted(time.Now().Add(-retention))
+	if err != nil {
+		return fmt.Errorf("failed to purge deleted products from MongoDB: %w", err)
+	}
+	if purged > 0 {
+		log.Printf("Purged %d deleted products", purged)
+	}
+	return nil
+}
+
+func (s *productService) StartPurgeSchedule() {
+	go func() {
+		ticker := time.NewTicker(purgeInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := s.PurgeDeletedProducts(); err != nil {
+				log.Printf("Failed to purge deleted products: %v", err)
+			}
+		}
+	}()
+}
@@ -3,12 +3,18 @@ package service
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 
 	"golang-ecommerce-search/internal/domain"
+	"golang-ecommerce-search/pkg/kafka"
 )
 
 // publishEvent publishes an event to Kafka with the given topic and payload
 func (s *productService) publishEvent(topic string, payload interface{}) error {
+	return publishEvent(s.producer, topic, payload)
+}
+
+func publishEvent(producer *kafka.Producer, topic string, payload interface{}) error {
 	var message string
 	switch p := payload.(type) {
 	case string:
@@ -21,7 +27,7 @@ func (s *productService) publishEvent(topic string, payload interface{}) error {
 		message = string(jsonBytes)
 	}
 
-	if err := s.producer.SendMessage(topic, message); err != nil {
+	if err := producer.SendMessage(topic, message); err != nil {
 		return fmt.Errorf("failed to publish event to topic %s: %w", topic, err)
 	}
 	return nil
@@ -29,6 +35,7 @@ func (s *productService) publishEvent(topic string, payload interface{}) error {
 
 // Event handlers for Elasticsearch synchronization
 func (s *productService) OnCreated(product *domain.Product) error {
+	s.embedProduct(product)
 	if err := s.esRepo.Create(product); err != nil {
 		return fmt.Errorf("failed to create product in Elasticsearch: %w", err)
 	}
@@ -36,12 +43,54 @@ func (s *productService) OnCreated(product *domain.Product) error {
 }
 
 func (s *productService) OnUpdated(product *domain.Product) error {
+	s.embedProduct(product)
+	return s.syncUpdate(product)
+}
+
+// OnChanged updates the changed fields of the indexed product, computing the
+// embedding again when the product text changed
+func (s *productService) OnChanged(change *domain.ProductChange) error {
+	if s.embedder != nil && change.AffectsEmbedding() {
+		product, err := s.mongoRepo.GetByID(change.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get product for embedding: %w", err)
+		}
+		s.embedProduct(product)
+		if product.Embedding != nil {
+			change.Fields["embedding"] = product.Embedding
+		}
+	}
+
+	if err := s.esRepo.UpdateFields(change.ID, change.Fields); err != nil {
+		return fmt.Errorf("failed to update product in Elasticsearch: %w", err)
+	}
+	return nil
+}
+
+// syncUpdate updates the indexed product, keeping its embedding unless a new
+// one was computed
+func (s *productService) syncUpdate(product *domain.Product) error {
 	if err := s.esRepo.Update(product); err != nil {
 		return fmt.Errorf("failed to update product in Elasticsearch: %w", err)
 	}
 	return nil
 }
 
+// embedProduct computes the embedding of a product for vector search. When
+// the embedder fails the product is still indexed for keyword search.
+func (s *productService) embedProduct(product *domain.Product) {
+	if s.embedder == nil {
+		return
+	}
+
+	embedding, err := s.embedder.Embed(domain.EmbeddingText(product))
+	if err != nil {
+		log.Printf("Failed to embed product %s: %v", product.ID, err)
+		return
+	}
+	product.Embedding = embedding
+}
+
 func (s *productService) OnDeleted(productID string) error {
 	if err := s.esRepo.Delete(productID); err != nil {
 		return fmt.Errorf("failed to delete product from Elasticsearch: %w", err)
@@ -54,7 +103,8 @@ func (s *productService) OnViewsIncremented(productID string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get product for views increment sync: %w", err)
 	}
-	return s.OnUpdated(product)
+	// Counters do not change the product text, so the embedding is kept
+	return s.syncUpdate(product)
 }
 
 func (s *productService) OnBuysIncremented(productID string) error {
@@ -62,5 +112,5 @@ func (s *productService) OnBuysIncremented(productID string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get product for buys increment sync: %w", err)
 	}
-	return s.OnUpdated(product)
+	return s.syncUpdate(product)
 }
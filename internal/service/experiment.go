@@ -0,0 +1,129 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"golang-ecommerce-search/internal/domain"
+	mongo "golang-ecommerce-search/internal/repository/mongodb"
+)
+
+// experimentCacheTTL is how long running experiments are cached before they
+// are reloaded from MongoDB
+const experimentCacheTTL = 30 * time.Second
+
+type ExperimentService interface {
+	domain.ExperimentService
+}
+
+type experimentService struct {
+	experimentRepo mongo.ExperimentRepository
+	searchLogRepo  mongo.SearchLogRepository
+
+	mu       sync.Mutex
+	running  []*domain.Experiment
+	loadedAt time.Time
+}
+
+func NewExperimentService(experimentRepo mongo.ExperimentRepository, searchLogRepo mongo.SearchLogRepository) ExperimentService {
+	return &experimentService{
+		experimentRepo: experimentRepo,
+		searchLogRepo:  searchLogRepo,
+	}
+}
+
+func (s *experimentService) CreateExperiment(experiment *domain.Experiment) error {
+	if err := s.experimentRepo.Create(experiment); err != nil {
+		return fmt.Errorf("failed to create experiment in MongoDB: %w", err)
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *experimentService) UpdateExperiment(experiment *domain.Experiment) error {
+	if err := s.experimentRepo.Update(experiment); err != nil {
+		return fmt.Errorf("failed to update experiment in MongoDB: %w", err)
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *experimentService) DeleteExperiment(id string) error {
+	if err := s.experimentRepo.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete experiment from MongoDB: %w", err)
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *experimentService) GetExperiment(id string) (*domain.Experiment, error) {
+	experiment, err := s.experimentRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get experiment from MongoDB: %w", err)
+	}
+	return experiment, nil
+}
+
+func (s *experimentService) ListExperiments() ([]*domain.Experiment, error) {
+	experiments, err := s.experimentRepo.List("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list experiments from MongoDB: %w", err)
+	}
+	return experiments, nil
+}
+
+// Assign returns the variant of the first running experiment the unit is
+// bucketed into, or nil when the unit is not part of any experiment
+func (s *experimentService) Assign(unitID string) (*domain.Assignment, error) {
+	if unitID == "" {
+		return nil, nil
+	}
+
+	experiments, err := s.runningExperiments()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, experiment := range experiments {
+		if variant := experiment.Assign(unitID); variant != nil {
+			return &domain.Assignment{ExperimentID: experiment.ID, Variant: *variant}, nil
+		}
+	}
+	return nil, nil
+}
+
+// Report returns the CTR, conversion rate and MRR of each variant of an experiment
+func (s *experimentService) Report(id string, query domain.AnalyticsQuery) ([]*domain.RankingStats, error) {
+	query.ExperimentID = id
+	query.GroupBy = domain.GroupByVariant
+
+	stats, err := s.searchLogRepo.RankingStats(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate experiment report in MongoDB: %w", err)
+	}
+	return stats, nil
+}
+
+func (s *experimentService) runningExperiments() ([]*domain.Experiment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running != nil && time.Since(s.loadedAt) < experimentCacheTTL {
+		return s.running, nil
+	}
+
+	experiments, err := s.experimentRepo.List(domain.ExperimentRunning)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load running experiments from MongoDB: %w", err)
+	}
+	s.running = experiments
+	s.loadedAt = time.Now()
+	return experiments, nil
+}
+
+func (s *experimentService) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = nil
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"golang-ecommerce-search/internal/domain"
+)
+
+func (s *productService) ReserveStock(productID string, adjustment *domain.StockAdjustment, audit domain.AuditContext) (*domain.StockChange, error) {
+	before, err := s.mongoRepo.GetByID(productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product from MongoDB: %w", err)
+	}
+	product, err := s.mongoRepo.ReserveStock(productID, adjustment.SKU, adjustment.Quantity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve stock in MongoDB: %w", err)
+	}
+	s.recordAudit(domain.AuditUpdate, before, product, audit)
+	return s.publishStockChange(product, adjustment.SKU)
+}
+
+func (s *productService) ReleaseStock(productID string, adjustment *domain.StockAdjustment, audit domain.AuditContext) (*domain.StockChange, error) {
+	before, err := s.mongoRepo.GetByID(productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product from MongoDB: %w", err)
+	}
+	product, err := s.mongoRepo.ReleaseStock(productID, adjustment.SKU, adjustment.Quantity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release stock in MongoDB: %w", err)
+	}
+	s.recordAudit(domain.AuditUpdate, before, product, audit)
+	return s.publishStockChange(product, adjustment.SKU)
+}
+
+func (s *productService) SetStock(productID string, adjustment *domain.StockAdjustment, audit domain.AuditContext) (*domain.StockChange, error) {
+	before, err := s.mongoRepo.GetByID(productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product from MongoDB: %w", err)
+	}
+	product, err := s.mongoRepo.SetStock(productID, adjustment.SKU, adjustment.Quantity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set stock in MongoDB: %w", err)
+	}
+	s.recordAudit(domain.AuditUpdate, before, product, audit)
+	return s.publishStockChange(product, adjustment.SKU)
+}
+
+// publishStockChange publishes the new stock and availability of a product so
+// the worker updates the index
+func (s *productService) publishStockChange(product *domain.Product, sku string) (*domain.StockChange, error) {
+	stock, _ := product.StockOf(sku)
+	change := &domain.StockChange{
+		ProductID:    product.ID,
+		SKU:          sku,
+		Stock:        stock,
+		Availability: product.Availability,
+		ChangedAt:    time.Now(),
+	}
+
+	if err := s.publishEvent(s.config.Kafka.Topic.ProductStockChanged, change); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// OnStockChanged re-indexes the stored product, whose stock may have changed
+// again since the event was published
+func (s *productService) OnStockChanged(change *domain.StockChange) error {
+	product, err := s.mongoRepo.GetByID(change.ProductID)
+	if err != nil {
+		return fmt.Errorf("failed to get product for stock sync: %w", err)
+	}
+	// Stock does not change the product text, so the embedding is kept
+	return s.syncUpdate(product)
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"golang-ecommerce-search/internal/domain"
+)
+
+// lifecycleScheduleInterval is how often the worker applies the publishing schedule
+const lifecycleScheduleInterval = time.Minute
+
+func (s *productService) ListProducts(params domain.ProductListParams) ([]*domain.Product, error) {
+	products, err := s.mongoRepo.List(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products from MongoDB: %w", err)
+	}
+	return products, nil
+}
+
+// SetLifecycle changes the status and schedule of a product like any other
+// update: it bumps the version, is audited and publishes the changed fields.
+// With a version set the change only applies to that version.
+func (s *productService) SetLifecycle(productID string, lifecycle *domain.Lifecycle, version int64, audit domain.AuditContext) (*domain.Product, error) {
+	before, err := s.mongoRepo.GetByID(productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product from MongoDB: %w", err)
+	}
+
+	if err := s.mongoRepo.UpdateLifecycle(productID, *lifecycle, version); err != nil {
+		return nil, fmt.Errorf("failed to update product status in MongoDB: %w", err)
+	}
+
+	after, err := s.mongoRepo.GetByID(productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get updated product from MongoDB: %w", err)
+	}
+	if err := s.publishEvent(s.config.Kafka.Topic.ProductUpdated, domain.NewProductChange(before, after)); err != nil {
+		return nil, err
+	}
+
+	s.recordAudit(domain.AuditUpdate, before, after, audit)
+	return after, nil
+}
+
+// RunLifecycleSchedule publishes and archives the products whose scheduled
+// dates have passed and updates them in the index
+func (s *productService) RunLifecycleSchedule() error {
+	changed, err := s.mongoRepo.ApplyLifecycleSchedule(time.Now())
+	for _, id := range changed {
+		product, getErr := s.mongoRepo.GetByID(id)
+		if getErr != nil {
+			log.Printf("Failed to get product %s for status sync: %v", id, getErr)
+			continue
+		}
+		if syncErr := s.syncUpdate(product); syncErr != nil {
+			log.Printf("Failed to sync status of product %s: %v", id, syncErr)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply publishing schedule in MongoDB: %w", err)
+	}
+	return nil
+}
+
+func (s *productService) StartLifecycleSchedule() {
+	go func() {
+		ticker := time.NewTicker(lifecycleScheduleInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := s.RunLifecycleSchedule(); err != nil {
+				log.Printf("Failed to run publishing schedule: %v", err)
+			}
+		}
+	}()
+}
@@ -2,31 +2,110 @@ package service
 
 import (
 	"fmt"
+	"log"
+	"time"
 
 	"golang-ecommerce-search/internal/domain"
+	"golang-ecommerce-search/internal/model"
 )
 
-func (s *productService) CreateProduct(product *domain.Product) error {
+func (s *productService) CreateProduct(product *domain.Product, audit domain.AuditContext) error {
+	if err := product.ValidateNew(s.config.Products.Categories, s.config.Products.MaxPrice); err != nil {
+		return err
+	}
+
+	product.RefreshAvailability()
+	product.SetLifecycle(product.Lifecycle())
 	if err := s.mongoRepo.Create(product); err != nil {
 		return fmt.Errorf("failed to create product in MongoDB: %w", err)
 	}
 
+	s.recordAudit(domain.AuditCreate, nil, product, audit)
 	return s.publishEvent(s.config.Kafka.Topic.ProductCreated, product)
 }
 
-func (s *productService) UpdateProduct(product *domain.Product) error {
+// UpdateProduct replaces the editable fields of a product, only if it is still
+// at the version of the given product when one is set, and refreshes the
+// product with the stored one
+func (s *productService) UpdateProduct(product *domain.Product, audit domain.AuditContext) error {
+	if err := product.Validate(s.config.Products.Categories, s.config.Products.MaxPrice); err != nil {
+		return err
+	}
+
+	before, err := s.mongoRepo.GetByID(product.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get product from MongoDB: %w", err)
+	}
+
+	after, err := s.saveUpdate(before, product)
+	if err != nil {
+		return err
+	}
+
+	s.recordAudit(domain.AuditUpdate, before, after, audit)
+	*product = *after
+	return nil
+}
+
+// PatchProduct applies a JSON Merge Patch to the editable fields of a
+// product. The patch is based on the version given, or else on the version
+// in the patch or the version just read.
+func (s *productService) PatchProduct(id string, patch []byte, version int64, audit domain.AuditContext) (*domain.Product, error) {
+	before, err := s.mongoRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product from MongoDB: %w", err)
+	}
+
+	patched := *before
+	if err := patched.ApplyMergePatch(patch); err != nil {
+		return nil, err
+	}
+	if err := patched.Validate(s.config.Products.Categories, s.config.Products.MaxPrice); err != nil {
+		return nil, err
+	}
+	if version > 0 {
+		patched.Version = version
+	}
+
+	after, err := s.saveUpdate(before, &patched)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordAudit(domain.AuditUpdate, before, after, audit)
+	return after, nil
+}
+
+// saveUpdate stores the editable fields of a product and publishes the fields
+// that changed, so the worker updates the index partially. It returns the
+// stored product.
+func (s *productService) saveUpdate(before, product *domain.Product) (*domain.Product, error) {
 	if err := s.mongoRepo.Update(product); err != nil {
-		return fmt.Errorf("failed to update product in MongoDB: %w", err)
+		return nil, fmt.Errorf("failed to update product in MongoDB: %w", err)
+	}
+
+	after, err := s.mongoRepo.GetByID(product.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get updated product from MongoDB: %w", err)
 	}
 
-	return s.publishEvent(s.config.Kafka.Topic.ProductUpdated, product)
+	if err := s.publishEvent(s.config.Kafka.Topic.ProductUpdated, domain.NewProductChange(before, after)); err != nil {
+		return nil, err
+	}
+	return after, nil
 }
 
-func (s *productService) DeleteProduct(id string) error {
+func (s *productService) DeleteProduct(id string, audit domain.AuditContext) error {
+	before, err := s.mongoRepo.GetByID(id)
+	if err != nil {
+		return fmt.Errorf("failed to get product from MongoDB: %w", err)
+	}
+
 	if err := s.mongoRepo.Delete(id); err != nil {
 		return fmt.Errorf("failed to delete product from MongoDB: %w", err)
 	}
 
+	s.recordAudit(domain.AuditDelete, before, nil, audit)
 	return s.publishEvent(s.config.Kafka.Topic.ProductDeleted, id)
 }
 
@@ -38,10 +117,170 @@ func (s *productService) GetProduct(id string) (*domain.Product, error) {
 	return product, nil
 }
 
-func (s *productService) SearchProducts(params domain.SearchParams) ([]*domain.Product, error) {
-	products, err := s.esRepo.Search(params)
+func (s *productService) SearchProducts(params domain.SearchParams) (*domain.SearchResult, error) {
+	start := time.Now()
+
+	// Queries redirected to a landing page do not return products
+	if params.Rules != nil && params.Rules.Redirect != nil {
+		result := &domain.SearchResult{
+			SearchID:       model.NewID().String(),
+			Products:       []*domain.Product{},
+			Redirect:       params.Rules.Redirect,
+			Interpretation: params.Interpretation,
+		}
+		go s.logSearch(params, result, 0, time.Since(start))
+		return result, nil
+	}
+
+	if err := s.embedQuery(&params); err != nil {
+		return nil, err
+	}
+
+	result, err := s.executeSearch(params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search products in Elasticsearch: %w", err)
 	}
+
+	// Recover from zero results of simple queries, but still log the original
+	// result count so zero-result queries remain visible. The total counts all
+	// pages, so later pages of a relaxed search are relaxed the same way.
+	resultCount := result.Total
+	if resultCount == 0 && params.Relax && params.Expression == nil {
+		relaxed, err := s.relaxSearch(params)
+		if err != nil {
+			return nil, err
+		}
+		if relaxed != nil {
+			result = relaxed
+		}
+	}
+
+	result.SearchID = model.NewID().String()
+	result.Interpretation = params.Interpretation
+	go s.logSearch(params, result, resultCount, time.Since(start))
+	return result, nil
+}
+
+// ExplainSearch runs the search with score explanations. Results are explained
+// as ranked by Elasticsearch, before diversification and relaxation.
+func (s *productService) ExplainSearch(params domain.SearchParams) (*domain.SearchExplanation, error) {
+	if err := s.embedQuery(&params); err != nil {
+		return nil, err
+	}
+
+	explanation, err := s.esRepo.Explain(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain search in Elasticsearch: %w", err)
+	}
+
+	explanation.RankingProfile = params.RankingProfile
+	if explanation.RankingProfile == "" {
+		explanation.RankingProfile = domain.DefaultRankingProfile
+	}
+	return explanation, nil
+}
+
+// embedQuery computes the query embedding of vector and hybrid searches
+func (s *productService) embedQuery(params *domain.SearchParams) error {
+	if params.Mode != domain.SearchModeVector && params.Mode != domain.SearchModeHybrid {
+		return nil
+	}
+	if s.embedder == nil {
+		return domain.ErrVectorSearchDisabled
+	}
+
+	vector, err := s.embedder.Embed(params.Query)
+	if err != nil {
+		return fmt.Errorf("failed to embed search query: %w", err)
+	}
+	params.Vector = vector
+	return nil
+}
+
+// diversityWindow is how many pages of candidates are fetched at once to
+// re-rank them for diversity
+const diversityWindow = 3
+
+// executeSearch runs the search in Elasticsearch. When results are diversified
+// the first diversityWindow pages are re-ranked from one window of candidates
+// and the requested page is taken from that order, so paging through them
+// neither repeats nor skips products. Later pages are served as ranked.
+func (s *productService) executeSearch(params domain.SearchParams) (*domain.SearchResult, error) {
+	if params.Diversity == nil || params.Page > diversityWindow {
+		return s.esRepo.Search(params)
+	}
+
+	window := params
+	window.Page = 1
+	window.PageSize = params.PageSize * diversityWindow
+	result, err := s.esRepo.Search(window)
+	if err != nil {
+		return nil, err
+	}
+
+	pinned := make(map[string]bool)
+	if params.Rules != nil {
+		for _, pin := range params.Rules.Pins {
+			pinned[pin.ProductID] = true
+		}
+	}
+	page := params.Page
+	if page < 1 {
+		page = 1
+	}
+	result.Products = domain.DiversifyPage(result.Products, params.Diversity.Field, params.Diversity.MaxPerGroup, page, params.PageSize, pinned)
+	return result, nil
+}
+
+// logSearch publishes a search log event for analytics. Failures are only
+// logged so that analytics never affects the search response.
+func (s *productService) logSearch(params domain.SearchParams, result *domain.SearchResult, resultCount int64, latency time.Duration) {
+	rankingProfile := params.RankingProfile
+	if rankingProfile == "" {
+		rankingProfile = domain.DefaultRankingProfile
+	}
+
+	// Log what the shopper typed rather than the query left after interpretation
+	query := params.Query
+	if params.Interpretation != nil {
+		query = params.Interpretation.OriginalQuery
+	}
+
+	searchLog := &domain.SearchLog{
+		ID:          result.SearchID,
+		Query:       domain.NormalizeQuery(query),
+		RawQuery:    query,
+		Categories:  params.Categories,
+		Brands:      params.Brands,
+		MinPrice:    params.MinPrice,
+		MaxPrice:    params.MaxPrice,
+		SortBy:      params.SortBy,
+		Page:        params.Page,
+		PageSize:    params.PageSize,
+		ResultCount: resultCount,
+		LatencyMs:   latency.Milliseconds(),
+		CreatedAt:   time.Now(),
+
+		RankingProfile: rankingProfile,
+		ExperimentID:   params.ExperimentID,
+		Variant:        params.Variant,
+	}
+	if result.Redirect != nil {
+		searchLog.RedirectURL = result.Redirect.URL
+	}
+	if result.Relaxation != nil {
+		searchLog.Relaxation = result.Relaxation.Strategy
+	}
+
+	if err := s.publishEvent(s.config.Kafka.Topic.SearchLogged, searchLog); err != nil {
+		log.Printf("Failed to publish search log: %v", err)
+	}
+}
+
+func (s *productService) SimilarProducts(id string, params domain.SearchParams) ([]*domain.Product, error) {
+	products, err := s.esRepo.Similar(id, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find similar products in Elasticsearch: %w", err)
+	}
 	return products, nil
 }
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"golang-ecommerce-search/internal/config"
+	"golang-ecommerce-search/internal/domain"
+	mongo "golang-ecommerce-search/internal/repository/mongodb"
+	"golang-ecommerce-search/pkg/kafka"
+)
+
+// maxOrderProducts bounds the number of products counted per order, since the
+// number of co-purchase pairs grows quadratically with the order size
+const maxOrderProducts = 50
+
+type PurchaseService interface {
+	domain.PurchaseService
+}
+
+type purchaseService struct {
+	coPurchaseRepo mongo.CoPurchaseRepository
+	productRepo    mongo.ProductRepository
+	producer       *kafka.Producer
+	config         *config.Config
+}
+
+func NewPurchaseService(coPurchaseRepo mongo.CoPurchaseRepository, productRepo mongo.ProductRepository, producer *kafka.Producer, cfg *config.Config) PurchaseService {
+	return &purchaseService{
+		coPurchaseRepo: coPurchaseRepo,
+		productRepo:    productRepo,
+		producer:       producer,
+		config:         cfg,
+	}
+}
+
+func (s *purchaseService) RecordPurchase(order *domain.Order) error {
+	order.ProductIDs = uniqueIDs(order.ProductIDs)
+	if len(order.ProductIDs) == 0 {
+		return domain.Invalidf("order must contain at least one product")
+	}
+	if len(order.ProductIDs) > maxOrderProducts {
+		return domain.Invalidf("order must not contain more than %d products", maxOrderProducts)
+	}
+	if order.CreatedAt.IsZero() {
+		order.CreatedAt = time.Now()
+	}
+
+	return publishEvent(s.producer, s.config.Kafka.Topic.ProductPurchased, order)
+}
+
+func (s *purchaseService) BoughtTogether(productID string, limit int) ([]*domain.BoughtTogether, error) {
+	pairs, err := s.coPurchaseRepo.TopCoPurchased(productID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get co-purchased products from MongoDB: %w", err)
+	}
+	if len(pairs) == 0 {
+		return []*domain.BoughtTogether{}, nil
+	}
+
+	ids := make([]string, len(pairs))
+	for i, pair := range pairs {
+		ids[i] = pair.OtherID
+	}
+
+	products, err := s.productRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products from MongoDB: %w", err)
+	}
+
+	byID := make(map[string]*domain.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	// Keep the co-purchase ranking and skip products that no longer exist
+	result := make([]*domain.BoughtTogether, 0, len(pairs))
+	for _, pair := range pairs {
+		if product, ok := byID[pair.OtherID]; ok {
+			result = append(result, &domain.BoughtTogether{Product: product, Count: pair.Count})
+		}
+	}
+	return result, nil
+}
+
+// OnPurchased updates the co-purchase counts for an order
+func (s *purchaseService) OnPurchased(order *domain.Order) error {
+	if err := s.coPurchaseRepo.IncrementPairs(uniqueIDs(order.ProductIDs)); err != nil {
+		return fmt.Errorf("failed to update co-purchase counts in MongoDB: %w", err)
+	}
+	return nil
+}
+
+// uniqueIDs trims and de-duplicates IDs, dropping empty ones
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"golang-ecommerce-search/internal/domain"
+	es "golang-ecommerce-search/internal/repository/elasticsearch"
+)
+
+const (
+	// vocabularyTTL is how long the brand and category dictionaries are cached
+	vocabularyTTL = 10 * time.Minute
+	// vocabularySize is the maximum number of brands and categories loaded
+	vocabularySize = 1000
+)
+
+type QueryUnderstandingService interface {
+	domain.QueryUnderstandingService
+}
+
+type queryUnderstandingService struct {
+	esRepo es.ProductRepository
+
+	mu       sync.Mutex
+	parser   *domain.QueryParser
+	loadedAt time.Time
+}
+
+func NewQueryUnderstandingService(esRepo es.ProductRepository) QueryUnderstandingService {
+	return &queryUnderstandingService{
+		esRepo: esRepo,
+	}
+}
+
+// Interpret extracts brand, category and price filters from a query using
+// the brands and categories currently in the index
+func (s *queryUnderstandingService) Interpret(query string) (*domain.Interpretation, error) {
+	parser, err := s.queryParser()
+	if err != nil {
+		return nil, err
+	}
+	return parser.Parse(query), nil
+}
+
+func (s *queryUnderstandingService) queryParser() (*domain.QueryParser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.parser != nil && time.Since(s.loadedAt) < vocabularyTTL {
+		return s.parser, nil
+	}
+
+	brands, err := s.esRepo.Terms("brand.keyword", vocabularySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load brands from Elasticsearch: %w", err)
+	}
+	categories, err := s.esRepo.Terms("category.keyword", vocabularySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories from Elasticsearch: %w", err)
+	}
+
+	s.parser = domain.NewQueryParser(brands, categories)
+	s.loadedAt = time.Now()
+	return s.parser, nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"fmt"
+
+	"golang-ecommerce-search/internal/domain"
+)
+
+// relaxSearch progressively relaxes a search that returned nothing: it drops
+// the least important filters one by one, switches to OR matching, enables
+// fuzziness, and finally falls back to the popular products of the requested
+// categories. It returns nil when no strategy finds any product.
+func (s *productService) relaxSearch(params domain.SearchParams) (*domain.SearchResult, error) {
+	relaxed := params
+	var dropped []string
+
+	// Drop filters, least important first
+	steps := []struct {
+		name   string
+		active bool
+		drop   func(*domain.SearchParams)
+	}{
+		{"price", relaxed.MinPrice != nil || relaxed.MaxPrice != nil, func(p *domain.SearchParams) { p.MinPrice, p.MaxPrice = nil, nil }},
+		{"brands", len(relaxed.Brands) > 0, func(p *domain.SearchParams) { p.Brands = nil }},
+		{"categories", len(relaxed.Categories) > 0, func(p *domain.SearchParams) { p.Categories = nil }},
+	}
+	for _, step := range steps {
+		if !step.active {
+			continue
+		}
+		step.drop(&relaxed)
+		dropped = append(dropped, step.name)
+
+		result, err := s.relaxedSearch(relaxed, domain.RelaxDropFilters, dropped)
+		if err != nil || result != nil {
+			return result, err
+		}
+	}
+
+	if relaxed.Query != "" {
+		// Match any of the query terms instead of all of them
+		if domain.GetRankingProfile(relaxed.RankingProfile).Operator != "or" {
+			relaxed.Operator = "or"
+			result, err := s.relaxedSearch(relaxed, domain.RelaxOrMatching, dropped)
+			if err != nil || result != nil {
+				return result, err
+			}
+		}
+
+		// Tolerate typos
+		relaxed.Operator = "or"
+		relaxed.Fuzzy = true
+		result, err := s.relaxedSearch(relaxed, domain.RelaxFuzzy, dropped)
+		if err != nil || result != nil {
+			return result, err
+		}
+	}
+
+	// Show the most popular products of the requested categories
+	if len(params.Categories) > 0 {
+		popular := domain.SearchParams{
+			Categories:     params.Categories,
+			Sort:           []domain.SortField{{Field: domain.SortFieldBuys, Order: domain.SortDesc}, {Field: domain.SortFieldViews, Order: domain.SortDesc}},
+			Page:           params.Page,
+			PageSize:       params.PageSize,
+			RankingProfile: params.RankingProfile,
+			Rules:          params.Rules,
+			Collapse:       params.Collapse,
+			Diversity:      params.Diversity,
+		}
+		return s.relaxedSearch(popular, domain.RelaxCategoryPopular, nil)
+	}
+
+	return nil, nil
+}
+
+// relaxedSearch runs a relaxed search and returns its result if it found anything
+func (s *productService) relaxedSearch(params domain.SearchParams, strategy string, dropped []string) (*domain.SearchResult, error) {
+	result, err := s.executeSearch(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run relaxed search (%s) in Elasticsearch: %w", strategy, err)
+	}
+	if result.Total == 0 {
+		return nil, nil
+	}
+
+	result.Relaxation = &domain.Relaxation{
+		Strategy:       strategy,
+		DroppedFilters: append([]string(nil), dropped...),
+	}
+	return result, nil
+}
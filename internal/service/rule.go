@@ -0,0 +1,157 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"golang-ecommerce-search/internal/domain"
+	mongo "golang-ecommerce-search/internal/repository/mongodb"
+)
+
+// ruleRefreshInterval is how often the cached enabled rules are reloaded
+// from MongoDB
+const ruleRefreshInterval = 30 * time.Second
+
+type RuleService interface {
+	domain.RuleService
+	// StartRefresh reloads the rule cache periodically in the background
+	StartRefresh()
+}
+
+type ruleService struct {
+	ruleRepo mongo.RuleRepository
+
+	mu      sync.RWMutex
+	enabled []*domain.Rule
+}
+
+func NewRuleService(ruleRepo mongo.RuleRepository) RuleService {
+	return &ruleService{
+		ruleRepo: ruleRepo,
+	}
+}
+
+func (s *ruleService) CreateRule(rule *domain.Rule) error {
+	if err := s.ruleRepo.Create(rule); err != nil {
+		return fmt.Errorf("failed to create rule in MongoDB: %w", err)
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *ruleService) UpdateRule(rule *domain.Rule) error {
+	if err := s.ruleRepo.Update(rule); err != nil {
+		return fmt.Errorf("failed to update rule in MongoDB: %w", err)
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *ruleService) DeleteRule(id string) error {
+	if err := s.ruleRepo.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete rule from MongoDB: %w", err)
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *ruleService) GetRule(id string) (*domain.Rule, error) {
+	rule, err := s.ruleRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rule from MongoDB: %w", err)
+	}
+	return rule, nil
+}
+
+func (s *ruleService) ListRules() ([]*domain.Rule, error) {
+	rules, err := s.ruleRepo.List(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rules from MongoDB: %w", err)
+	}
+	return rules, nil
+}
+
+// MatchRules returns the combined effects of the active rules matching the
+// query, or nil when no rule matches
+func (s *ruleService) MatchRules(query string) (*domain.RuleEffects, error) {
+	rules, err := s.enabledRules()
+	if err != nil {
+		return nil, err
+	}
+
+	query = domain.NormalizeQuery(query)
+	now := time.Now()
+
+	var matched []*domain.Rule
+	for _, rule := range rules {
+		if rule.Active(now) && rule.Matches(query) {
+			matched = append(matched, rule)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, nil
+	}
+	return domain.ApplyRules(matched), nil
+}
+
+// RefreshRules reloads the enabled rules into the cache. Rules that fail to
+// compile are skipped so one bad rule does not disable the others.
+func (s *ruleService) RefreshRules() error {
+	rules, err := s.ruleRepo.List(true)
+	if err != nil {
+		return fmt.Errorf("failed to load rules from MongoDB: %w", err)
+	}
+
+	compiled := make([]*domain.Rule, 0, len(rules))
+	for _, rule := range rules {
+		if err := rule.Compile(); err != nil {
+			log.Printf("Skipping merchandising rule: %v", err)
+			continue
+		}
+		compiled = append(compiled, rule)
+	}
+
+	s.mu.Lock()
+	s.enabled = compiled
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *ruleService) StartRefresh() {
+	go func() {
+		ticker := time.NewTicker(ruleRefreshInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := s.RefreshRules(); err != nil {
+				log.Printf("Failed to refresh merchandising rules: %v", err)
+			}
+		}
+	}()
+}
+
+// enabledRules returns the cached rules, loading them when the cache is empty
+func (s *ruleService) enabledRules() ([]*domain.Rule, error) {
+	s.mu.RLock()
+	rules := s.enabled
+	s.mu.RUnlock()
+	if rules != nil {
+		return rules, nil
+	}
+
+	if err := s.RefreshRules(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.enabled, nil
+}
+
+// invalidate drops the cache so admin changes apply to the next search
+func (s *ruleService) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enabled = nil
+}
@@ -10,19 +10,30 @@ import (
 
 type ProductService interface {
 	domain.ProductService
+	// StartLifecycleSchedule periodically publishes and archives products
+	// according to their schedule; it runs in the worker
+	StartLifecycleSchedule()
+	// StartPurgeSchedule periodically removes products soft deleted longer
+	// than the retention period; it runs in the worker
+	StartPurgeSchedule()
 }
 
 type productService struct {
 	esRepo    es.ProductRepository
 	mongoRepo mongo.ProductRepository
-	producer  *kafka.Producer
-	config    *config.Config
+	auditRepo mongo.AuditRepository
+	// embedder computes product and query embeddings; nil disables vector search
+	embedder domain.Embedder
+	producer *kafka.Producer
+	config   *config.Config
 }
 
-func NewProductService(esRepo es.ProductRepository, mongoRepo mongo.ProductRepository, producer *kafka.Producer, cfg *config.Config) ProductService {
+func NewProductService(esRepo es.ProductRepository, mongoRepo mongo.ProductRepository, auditRepo mongo.AuditRepository, embedder domain.Embedder, producer *kafka.Producer, cfg *config.Config) ProductService {
 	return &productService{
 		esRepo:    esRepo,
 		mongoRepo: mongoRepo,
+		auditRepo: auditRepo,
+		embedder:  embedder,
 		producer:  producer,
 		config:    cfg,
 	}
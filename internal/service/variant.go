@@ -0,0 +1,67 @@
+package service
+
+import (
+	"fmt"
+
+	"golang-ecommerce-search/internal/domain"
+)
+
+func (s *productService) ListVariants(productID string) ([]domain.ProductVariant, error) {
+	product, err := s.GetProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.Variants == nil {
+		return []domain.ProductVariant{}, nil
+	}
+	return product.Variants, nil
+}
+
+func (s *productService) AddVariant(productID string, variant *domain.ProductVariant, audit domain.AuditContext) error {
+	before, err := s.mongoRepo.GetByID(productID)
+	if err != nil {
+		return fmt.Errorf("failed to get product from MongoDB: %w", err)
+	}
+	if err := s.mongoRepo.AddVariant(productID, variant); err != nil {
+		return fmt.Errorf("failed to add variant in MongoDB: %w", err)
+	}
+	return s.variantsChanged(before, audit)
+}
+
+func (s *productService) UpdateVariant(productID string, variant *domain.ProductVariant, audit domain.AuditContext) error {
+	before, err := s.mongoRepo.GetByID(productID)
+	if err != nil {
+		return fmt.Errorf("failed to get product from MongoDB: %w", err)
+	}
+	if err := s.mongoRepo.UpdateVariant(productID, variant); err != nil {
+		return fmt.Errorf("failed to update variant in MongoDB: %w", err)
+	}
+	return s.variantsChanged(before, audit)
+}
+
+func (s *productService) DeleteVariant(productID, sku string, audit domain.AuditContext) error {
+	before, err := s.mongoRepo.GetByID(productID)
+	if err != nil {
+		return fmt.Errorf("failed to get product from MongoDB: %w", err)
+	}
+	if err := s.mongoRepo.DeleteVariant(productID, sku); err != nil {
+		return fmt.Errorf("failed to delete variant from MongoDB: %w", err)
+	}
+	return s.variantsChanged(before, audit)
+}
+
+// variantsChanged recomputes the availability, which depends on the stock of
+// the variants, records the change and re-indexes the product with all of its
+// fields, including the variants
+func (s *productService) variantsChanged(before *domain.Product, audit domain.AuditContext) error {
+	if err := s.mongoRepo.RefreshAvailability(before.ID); err != nil {
+		return fmt.Errorf("failed to refresh availability in MongoDB: %w", err)
+	}
+
+	after, err := s.mongoRepo.GetByID(before.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get updated product from MongoDB: %w", err)
+	}
+	s.recordAudit(domain.AuditUpdate, before, after, audit)
+	return s.publishEvent(s.config.Kafka.Topic.ProductUpdated, after)
+}
@@ -59,7 +59,6 @@ var (
 )
 
 func generateProduct() *domain.Product {
-	now := time.Now()
 	return &domain.Product{
 		Name:        fmt.Sprintf("%s %s %d", adjectives[rand.Intn(len(adjectives))], nouns[rand.Intn(len(nouns))], rand.Intn(1000)),
 		Description: fmt.Sprintf("This is a %s %s with amazing features and quality.", adjectives[rand.Intn(len(adjectives))], nouns[rand.Intn(len(nouns))]),
@@ -67,77 +66,98 @@ func generateProduct() *domain.Product {
 		Category:    categories[rand.Intn(len(categories))],
 		Tags:        []string{categories[rand.Intn(len(categories))], categories[rand.Intn(len(categories))]},
 		Brand:       brands[rand.Intn(len(brands))],
-		Views:       rand.Int63n(10000),
-		Buys:        rand.Int63n(1000),
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		Status:      domain.StatusPublished,
 	}
 }
 
-func sendRequest(product *domain.Product, wg *sync.WaitGroup, successChan chan<- bool, errorChan chan<- error) {
+// sendBatch creates a batch of products with the bulk endpoint and reports
+// how many were created
+func sendBatch(size int, wg *sync.WaitGroup, results chan<- batchResult) {
 	defer wg.Done()
 
-	jsonData, err := json.Marshal(product)
+	operations := make([]domain.BulkOperation, size)
+	for i := range operations {
+		operations[i] = domain.BulkOperation{Action: domain.BulkUpsert, Product: generateProduct()}
+	}
+
+	jsonData, err := json.Marshal(map[string]interface{}{"operations": operations})
 	if err != nil {
-		errorChan <- fmt.Errorf("error marshaling product: %v", err)
+		results <- batchResult{failed: size, err: fmt.Errorf("error marshaling products: %v", err)}
 		return
 	}
 
-	resp, err := http.Post("http://localhost:8080/products", "application/json", bytes.NewBuffer(jsonData))
+	resp, err := http.Post("http://localhost:8080/products/bulk", "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
-		errorChan <- fmt.Errorf("error sending request: %v", err)
+		results <- batchResult{failed: size, err: fmt.Errorf("error sending request: %v", err)}
 		return
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated {
-		errorChan <- fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		results <- batchResult{failed: size, err: fmt.Errorf("unexpected status code: %d", resp.StatusCode)}
 		return
 	}
 
-	successChan <- true
+	var result domain.BulkResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		results <- batchResult{failed: size, err: fmt.Errorf("error decoding response: %v", err)}
+		return
+	}
+	for _, item := range result.Items {
+		if item.Status == domain.BulkFailed {
+			err = fmt.Errorf("product %d of batch failed: %s", item.Index, item.Message)
+			break
+		}
+	}
+	results <- batchResult{created: result.Created, failed: result.Failed, err: err}
+}
+
+type batchResult struct {
+	created int
+	failed  int
+	err     error
 }
 
 func main() {
 	rand.Seed(time.Now().UnixNano())
 
-	const totalRequests = 10_000_000
-	const concurrentRequests = 50
+	const totalProducts = 10_000_000
+	const batchSize = 500
+	const concurrentBatches = 8
 
 	var wg sync.WaitGroup
-	successChan := make(chan bool, totalRequests)
-	errorChan := make(chan error, totalRequests)
+	results := make(chan batchResult, concurrentBatches)
 
 	startTime := time.Now()
 	successCount := 0
 	errorCount := 0
 
-	fmt.Printf("Starting load test with %d total requests and %d concurrent requests\n", totalRequests, concurrentRequests)
+	fmt.Printf("Starting load test with %d products in batches of %d, %d batches at a time\n", totalProducts, batchSize, concurrentBatches)
 
-	for i := 0; i < totalRequests; i += concurrentRequests {
-		batchSize := concurrentRequests
-		if i+batchSize > totalRequests {
-			batchSize = totalRequests - i
-		}
-
-		for j := 0; j < batchSize; j++ {
+	for i := 0; i < totalProducts; i += batchSize * concurrentBatches {
+		batches := 0
+		for j := 0; j < concurrentBatches && i+j*batchSize < totalProducts; j++ {
+			size := batchSize
+			if remaining := totalProducts - i - j*batchSize; remaining < size {
+				size = remaining
+			}
 			wg.Add(1)
-			go sendRequest(generateProduct(), &wg, successChan, errorChan)
+			batches++
+			go sendBatch(size, &wg, results)
 		}
 
-		// Process results for this batch
-		for j := 0; j < batchSize; j++ {
-			select {
-			case <-successChan:
-				successCount++
-			case err := <-errorChan:
-				errorCount++
-				fmt.Printf("Error: %v\n", err)
+		// Process results for these batches
+		for j := 0; j < batches; j++ {
+			result := <-results
+			successCount += result.created
+			errorCount += result.failed
+			if result.err != nil {
+				fmt.Printf("Error: %v\n", result.err)
 			}
 		}
 
 		// Print progress
-		progress := float64(i+batchSize) / float64(totalRequests) * 100
+		progress := float64(successCount+errorCount) / float64(totalProducts) * 100
 		fmt.Printf("Progress: %.2f%% (Success: %d, Errors: %d)\n", progress, successCount, errorCount)
 	}
 
@@ -145,8 +165,8 @@ func main() {
 	duration := time.Since(startTime)
 
 	fmt.Printf("\nLoad test completed in %v\n", duration)
-	fmt.Printf("Total requests: %d\n", totalRequests)
-	fmt.Printf("Successful requests: %d\n", successCount)
-	fmt.Printf("Failed requests: %d\n", errorCount)
-	fmt.Printf("Requests per second: %.2f\n", float64(totalRequests)/duration.Seconds())
+	fmt.Printf("Total products: %d\n", totalProducts)
+	fmt.Printf("Created products: %d\n", successCount)
+	fmt.Printf("Failed products: %d\n", errorCount)
+	fmt.Printf("Products per second: %.2f\n", float64(totalProducts)/duration.Seconds())
 }